@@ -0,0 +1,141 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shuldan/cli"
+)
+
+// profileEndpoints maps --type to a path below the diagnostics prefix;
+// timed ones take ?seconds=.
+var profileEndpoints = map[string]struct {
+	path  string
+	timed bool
+}{
+	"cpu":       {"/pprof/profile", true},
+	"trace":     {"/trace", true},
+	"heap":      {"/pprof/heap", false},
+	"allocs":    {"/pprof/allocs", false},
+	"goroutine": {"/pprof/goroutine", false},
+	"block":     {"/pprof/block", false},
+	"mutex":     {"/pprof/mutex", false},
+}
+
+// DebugProfile captures a profile from a running process through its
+// diagnostics endpoints; baseURL is the default for --url, e.g.
+// "http://127.0.0.1:9090/debug".
+func DebugProfile(baseURL string) cli.Command {
+	return &debugProfileCommand{baseURL: baseURL, client: http.DefaultClient}
+}
+
+type debugProfileCommand struct {
+	baseURL string
+	client  *http.Client
+}
+
+func (c *debugProfileCommand) Name() string { return "debug:profile" }
+func (c *debugProfileCommand) Description() string {
+	return "Capture a CPU/heap profile or trace from the running process"
+}
+func (c *debugProfileCommand) Group() string   { return "server" }
+func (c *debugProfileCommand) Args() []cli.Arg { return nil }
+
+func (c *debugProfileCommand) Options() []cli.Option {
+	return []cli.Option{
+		cli.StringOption("url", "u", c.baseURL,
+			"Diagnostics base URL"),
+		cli.StringOption("type", "t", "cpu",
+			"cpu, heap, allocs, goroutine, block, mutex or trace"),
+		cli.IntOption("seconds", "s", 30,
+			"Capture duration for cpu and trace"),
+		cli.StringOption("output", "o", "",
+			"Output file (default <type>.pprof, trace.out for trace)"),
+		cli.StringOption("token", "", "",
+			"Bearer token for protected endpoints"),
+	}
+}
+
+func (c *debugProfileCommand) Execute(
+	ctx context.Context,
+	_ io.Reader, out io.Writer, input *cli.Input,
+) error {
+	kind := input.StringOption("type")
+
+	endpoint, ok := profileEndpoints[kind]
+	if !ok {
+		return fmt.Errorf("debug:profile: unknown profile type %q", kind)
+	}
+
+	base := strings.TrimRight(input.StringOption("url"), "/")
+	if base == "" {
+		return fmt.Errorf("debug:profile: --url is required")
+	}
+
+	url := base + endpoint.path
+	timeout := 30 * time.Second
+
+	if endpoint.timed {
+		seconds := max(1, input.IntOption("seconds"))
+		url += fmt.Sprintf("?seconds=%d", seconds)
+		timeout += time.Duration(seconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	data, err := c.fetch(ctx, url, input.StringOption("token"))
+	if err != nil {
+		return err
+	}
+
+	path := input.StringOption("output")
+	if path == "" {
+		path = kind + ".pprof"
+		if kind == "trace" {
+			path = "trace.out"
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("debug:profile: write %s: %w", path, err)
+	}
+
+	_, _ = fmt.Fprintf(out, "%s profile written to %s (%d bytes)\n", kind, path, len(data))
+
+	return nil
+}
+
+func (c *debugProfileCommand) fetch(ctx context.Context, url, token string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("debug:profile: %w", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("debug:profile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("debug:profile: read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("debug:profile: %s: %s %s",
+			url, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
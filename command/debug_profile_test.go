@@ -0,0 +1,69 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shuldan/framework/httpserver"
+	"github.com/shuldan/framework/httpserver/admin"
+	"github.com/shuldan/framework/httpserver/diagnostics"
+)
+
+func TestDebugProfile_Heap(t *testing.T) {
+	t.Parallel()
+	router := httpserver.NewRouter()
+	diagnostics.Mount(router, diagnostics.Config{})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "heap.pprof")
+	output, err := runCommand(t, DebugProfile(srv.URL+"/debug"), "--type=heap", "--output="+path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertContains(t, output, "heap profile written to "+path)
+
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		t.Fatalf("expected profile data, got %d bytes, %v", len(data), err)
+	}
+	assertCliCommand(t, DebugProfile(""), "debug:profile", "server")
+}
+
+func TestDebugProfile_CPUWithToken(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(admin.NewRouter(admin.Config{Auth: admin.BearerToken("t0ken")}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cpu.pprof")
+	_, err := runCommand(t, DebugProfile(srv.URL+"/debug"), "--seconds=1", "--output="+path)
+	if err == nil {
+		t.Fatal("expected error without token")
+	}
+	assertContains(t, err.Error(), "401")
+
+	_, err = runCommand(t, DebugProfile(srv.URL+"/debug"), "--seconds=1", "--token=t0ken", "--output="+path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Size() == 0 {
+		t.Fatalf("expected cpu profile, got %v", err)
+	}
+}
+
+func TestDebugProfile_UnknownType(t *testing.T) {
+	t.Parallel()
+	_, err := runCommand(t, DebugProfile("http://127.0.0.1:1/debug"), "--type=disk")
+	if err == nil {
+		t.Fatal("expected error for unknown type")
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	if _, err := runCommand(t, DebugProfile(srv.URL), "--type=heap", "--output="+filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Fatal("expected error for non-200 response")
+	}
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shuldan/cli"
+
+	"github.com/shuldan/framework/httpserver"
+	"github.com/shuldan/framework/httpserver/openapi"
+)
+
+func OpenAPIExport(router *httpserver.Router, cfg openapi.Config) cli.Command {
+	return &openAPIExportCommand{router: router, cfg: cfg}
+}
+
+type openAPIExportCommand struct {
+	router *httpserver.Router
+	cfg    openapi.Config
+}
+
+func (c *openAPIExportCommand) Name() string        { return "openapi:export" }
+func (c *openAPIExportCommand) Description() string { return "Export OpenAPI document for HTTP routes" }
+func (c *openAPIExportCommand) Group() string       { return "server" }
+func (c *openAPIExportCommand) Args() []cli.Arg     { return nil }
+
+func (c *openAPIExportCommand) Options() []cli.Option {
+	return []cli.Option{
+		cli.StringOption("output", "o", "",
+			"Write to file instead of stdout"),
+	}
+}
+
+func (c *openAPIExportCommand) Execute(
+	_ context.Context,
+	_ io.Reader, out io.Writer, input *cli.Input,
+) error {
+	doc := openapi.Generate(c.router.Routes(), c.cfg)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("openapi: encode document: %w", err)
+	}
+
+	data = append(data, '\n')
+
+	path := input.StringOption("output")
+	if path == "" {
+		_, err = out.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("openapi: write %s: %w", path, err)
+	}
+
+	_, _ = fmt.Fprintf(out, "OpenAPI document written to %s\n", path)
+
+	return nil
+}
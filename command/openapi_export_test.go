@@ -0,0 +1,42 @@
+package command
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shuldan/framework/httpserver/openapi"
+)
+
+func TestOpenAPIExport_Stdout(t *testing.T) {
+	t.Parallel()
+	cfg := openapi.Config{Info: openapi.Info{Title: "API", Version: "1.0"}}
+	output, err := runCommand(t, OpenAPIExport(testRouter(), cfg))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Info.Title != "API" || len(doc.Paths) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	assertCliCommand(t, OpenAPIExport(nil, cfg), "openapi:export", "server")
+}
+
+func TestOpenAPIExport_File(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	output, err := runCommand(t, OpenAPIExport(testRouter(), openapi.Config{}), "--output="+path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertContains(t, output, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, string(data), `"openapi": "3.1.0"`)
+}
@@ -0,0 +1,96 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shuldan/cli"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func RoutesList(router *httpserver.Router) cli.Command {
+	return &routesListCommand{router: router}
+}
+
+type routesListCommand struct {
+	router *httpserver.Router
+}
+
+func (c *routesListCommand) Name() string        { return "routes:list" }
+func (c *routesListCommand) Description() string { return "List registered HTTP routes" }
+func (c *routesListCommand) Group() string       { return "server" }
+func (c *routesListCommand) Args() []cli.Arg     { return nil }
+
+func (c *routesListCommand) Options() []cli.Option {
+	return []cli.Option{
+		cli.StringOption("format", "f", "table",
+			"Output format: table or json"),
+		cli.StringOption("method", "m", "",
+			"Show only routes with this HTTP method"),
+	}
+}
+
+func (c *routesListCommand) Execute(
+	_ context.Context,
+	_ io.Reader, out io.Writer, input *cli.Input,
+) error {
+	routes := filterRoutes(c.router.Routes(), input.StringOption("method"))
+
+	switch format := input.StringOption("format"); format {
+	case "", "table":
+		writeRoutesTable(out, routes)
+		return nil
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(routes)
+	default:
+		return fmt.Errorf("unknown format %q (want table or json)", format)
+	}
+}
+
+func filterRoutes(
+	routes []httpserver.Route, method string,
+) []httpserver.Route {
+	if method == "" {
+		return routes
+	}
+
+	filtered := routes[:0]
+
+	for _, r := range routes {
+		if strings.EqualFold(r.Method, method) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered
+}
+
+func writeRoutesTable(w io.Writer, routes []httpserver.Route) {
+	if len(routes) == 0 {
+		_, _ = fmt.Fprintln(w, "No routes registered")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "METHOD\tPATTERN\tHANDLER\tMIDDLEWARE")
+
+	for _, r := range routes {
+		mw := strings.Join(r.Middleware, ", ")
+		if mw == "" {
+			mw = "-"
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			r.Method, r.Pattern, r.Handler, mw)
+	}
+
+	_ = tw.Flush()
+}
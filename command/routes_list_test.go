@@ -0,0 +1,62 @@
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func testRouter() *httpserver.Router {
+	router := httpserver.NewRouter()
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router.GET("/health", noop)
+	router.Group("/api").POST("/users", noop)
+
+	return router
+}
+
+func TestRoutesList_Table(t *testing.T) {
+	t.Parallel()
+	output, err := runCommand(t, RoutesList(testRouter()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertContains(t, output, "METHOD")
+	assertContains(t, output, "POST    /api/users")
+	assertContains(t, output, "GET     /health")
+}
+
+func TestRoutesList_JSONWithFilter(t *testing.T) {
+	t.Parallel()
+	output, err := runCommand(t, RoutesList(testRouter()), "--format=json", "--method=get")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var routes []httpserver.Route
+	if err := json.Unmarshal([]byte(output), &routes); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(routes) != 1 || routes[0].Pattern != "/health" {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+}
+
+func TestRoutesList_Empty(t *testing.T) {
+	t.Parallel()
+	output, err := runCommand(t, RoutesList(httpserver.NewRouter()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertContains(t, output, "No routes registered")
+}
+
+func TestRoutesList_UnknownFormat(t *testing.T) {
+	t.Parallel()
+	_, err := runCommand(t, RoutesList(testRouter()), "--format=xml")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	assertCliCommand(t, RoutesList(nil), "routes:list", "server")
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	domainerrors "github.com/shuldan/errors"
+
+	"github.com/shuldan/framework/httpserver"
+	"github.com/shuldan/framework/httpserver/diagnostics"
+)
+
+const DefaultName = "admin"
+
+var ErrUnauthorized = domainerrors.NewCode("ADMIN_UNAUTHORIZED").
+	Kind(domainerrors.Authentication).
+	New("admin credentials required")
+
+var ErrUnknownLevel = domainerrors.NewCode("ADMIN_UNKNOWN_LOG_LEVEL").
+	Kind(domainerrors.Validation).
+	New("unknown log level")
+
+// LevelSetter is satisfied by *logger.Logger.
+type LevelSetter interface {
+	Level() string
+	SetLevel(level string) error
+}
+
+// Config describes the admin server. /healthz and /readyz stay public
+// for probes; every other endpoint is behind Auth.
+type Config struct {
+	// Server is the listener configuration; Name defaults to "admin"
+	// and Host to 127.0.0.1.
+	Server httpserver.Config
+	// Auth guards the admin endpoints; nil = no auth (see BearerToken,
+	// BasicAuth).
+	Auth httpserver.Middleware
+	// Health backs /healthz, e.g. app.Application.Health; nil = always ok.
+	Health func(ctx context.Context) error
+	// Ready backs /readyz; nil = same as Health.
+	Ready func(ctx context.Context) error
+	// Servers are reported by the built-in /metrics.
+	Servers []*httpserver.Module
+	// Metrics replaces the built-in /metrics handler.
+	Metrics http.Handler
+	// Logger enables GET/PUT /loglevel.
+	Logger LevelSetter
+	// Version is reported by /buildinfo next to the module build info.
+	Version string
+	// DisableDiagnostics drops the /debug endpoints (pprof, goroutines,
+	// memstats, trace).
+	DisableDiagnostics bool
+	// ProblemDetails switches admin errors to application/problem+json
+	// independently of the public routers; nil = plain JSON errors.
+	ProblemDetails *httpserver.ProblemConfig
+}
+
+// NewModule returns the admin server as a separate httpserver.Module.
+func NewModule(cfg Config) *httpserver.Module {
+	srv := cfg.Server
+	if srv.Name == "" {
+		srv.Name = DefaultName
+	}
+
+	if srv.Host == "" && len(srv.Listeners) == 0 {
+		srv.Host = "127.0.0.1"
+	}
+
+	return httpserver.NewModule(NewRouter(cfg), srv)
+}
+
+// NewRouter returns the admin routes, for mounting extra endpoints or
+// serving them from an existing server.
+func NewRouter(cfg Config) *httpserver.Router {
+	router := httpserver.NewRouter()
+	if cfg.ProblemDetails != nil {
+		router.UseProblemDetails(*cfg.ProblemDetails)
+	}
+
+	hidden := httpserver.WithHidden()
+	router.GET("/healthz", probe(cfg.Health), hidden)
+	router.GET("/readyz", probe(firstNonNil(cfg.Ready, cfg.Health)), hidden)
+
+	protected := router
+	if cfg.Auth != nil {
+		protected = router.Group("", cfg.Auth)
+	}
+
+	metrics := cfg.Metrics
+	if metrics == nil {
+		metrics = metricsHandler(cfg.Servers)
+	}
+
+	protected.Handle(http.MethodGet, "/metrics", metrics, hidden)
+
+	if cfg.Logger != nil {
+		protected.GET("/loglevel", getLevel(cfg.Logger), hidden)
+		protected.PUT("/loglevel", setLevel(cfg.Logger), hidden)
+	}
+
+	protected.GET("/buildinfo", diagnostics.BuildInfoHandler(cfg.Version), hidden)
+
+	if !cfg.DisableDiagnostics {
+		diagnostics.Mount(protected, diagnostics.Config{
+			Guard:   diagnostics.Unguarded,
+			Version: cfg.Version,
+		})
+	}
+
+	return router
+}
+
+// BearerToken accepts requests with "Authorization: Bearer <token>".
+func BearerToken(token string) httpserver.Middleware {
+	want := []byte("Bearer " + token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(got, want) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				httpserver.Error(w, ErrUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BasicAuth accepts requests with the given HTTP Basic credentials.
+func BasicAuth(user, password string) httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, p, ok := r.BasicAuth()
+			userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+			passOK := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+
+			if !ok || !userOK || !passOK {
+				w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+				httpserver.Error(w, ErrUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func probe(check func(ctx context.Context) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			if err := check(r.Context()); err != nil {
+				httpserver.Error(w, httpserver.ErrNotReady.WithCause(err))
+				return
+			}
+		}
+
+		httpserver.OK(w, map[string]string{"status": "ok"})
+	}
+}
+
+func firstNonNil(fns ...func(ctx context.Context) error) func(ctx context.Context) error {
+	for _, fn := range fns {
+		if fn != nil {
+			return fn
+		}
+	}
+
+	return nil
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+func getLevel(l LevelSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		httpserver.OK(w, levelBody{Level: l.Level()})
+	}
+}
+
+func setLevel(l LevelSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body levelBody
+		if err := httpserver.Bind(r, &body); err != nil {
+			httpserver.Error(w, err)
+			return
+		}
+
+		if err := l.SetLevel(body.Level); err != nil {
+			httpserver.Error(w, ErrUnknownLevel.WithDetail("level", body.Level))
+			return
+		}
+
+		httpserver.OK(w, levelBody{Level: l.Level()})
+	}
+}
@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+type fakeLevel struct{ level string }
+
+func (f *fakeLevel) Level() string { return f.level }
+
+func (f *fakeLevel) SetLevel(level string) error {
+	if level != "debug" && level != "info" {
+		return errors.New("bad level")
+	}
+	f.level = level
+
+	return nil
+}
+
+func do(h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func assertStatus(t *testing.T, expected int, rr *httptest.ResponseRecorder) {
+	t.Helper()
+	if rr.Code != expected {
+		t.Fatalf("status: expected %d, got %d (%s)", expected, rr.Code, rr.Body.String())
+	}
+}
+
+func assertContains(t *testing.T, s, substr string) {
+	t.Helper()
+	if !strings.Contains(s, substr) {
+		t.Errorf("expected %q to contain %q", s, substr)
+	}
+}
+
+func TestRouter_AuthProtectsAllButProbes(t *testing.T) {
+	t.Parallel()
+	router := NewRouter(Config{Auth: BearerToken("s3cret")})
+
+	assertStatus(t, http.StatusOK, do(router, "GET", "/healthz", ""))
+	assertStatus(t, http.StatusOK, do(router, "GET", "/readyz", ""))
+
+	rr := do(router, "GET", "/metrics", "")
+	assertStatus(t, http.StatusUnauthorized, rr)
+	assertContains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+	assertStatus(t, http.StatusUnauthorized, do(router, "GET", "/debug/pprof/", "", "Authorization", "Bearer wrong"))
+
+	assertStatus(t, http.StatusOK, do(router, "GET", "/debug/pprof/", "", "Authorization", "Bearer s3cret"))
+	assertStatus(t, http.StatusOK, do(router, "GET", "/buildinfo", "", "Authorization", "Bearer s3cret"))
+}
+
+func TestRouter_BasicAuth(t *testing.T) {
+	t.Parallel()
+	router := NewRouter(Config{Auth: BasicAuth("ops", "pw")})
+	req := httptest.NewRequest("GET", "/buildinfo", nil)
+	req.SetBasicAuth("ops", "pw")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assertStatus(t, http.StatusOK, rr)
+	assertContains(t, rr.Body.String(), "go_version")
+
+	assertStatus(t, http.StatusUnauthorized, do(router, "GET", "/buildinfo", ""))
+}
+
+func TestRouter_Probes(t *testing.T) {
+	t.Parallel()
+	down := errors.New("db down")
+	router := NewRouter(Config{
+		Health: func(context.Context) error { return nil },
+		Ready:  func(context.Context) error { return down },
+	})
+
+	assertStatus(t, http.StatusOK, do(router, "GET", "/healthz", ""))
+	rr := do(router, "GET", "/readyz", "")
+	assertStatus(t, http.StatusServiceUnavailable, rr)
+	assertContains(t, rr.Body.String(), "SERVER_NOT_READY")
+}
+
+func TestRouter_LogLevel(t *testing.T) {
+	t.Parallel()
+	lvl := &fakeLevel{level: "info"}
+	router := NewRouter(Config{Logger: lvl})
+
+	rr := do(router, "GET", "/loglevel", "")
+	assertContains(t, rr.Body.String(), `"level":"info"`)
+
+	rr = do(router, "PUT", "/loglevel", `{"level":"debug"}`)
+	assertStatus(t, http.StatusOK, rr)
+	if lvl.level != "debug" {
+		t.Fatalf("expected level changed, got %q", lvl.level)
+	}
+
+	rr = do(router, "PUT", "/loglevel", `{"level":"loud"}`)
+	assertStatus(t, http.StatusBadRequest, rr)
+	assertContains(t, rr.Body.String(), "ADMIN_UNKNOWN_LOG_LEVEL")
+
+	assertStatus(t, http.StatusNotFound, do(NewRouter(Config{}), "GET", "/loglevel", ""))
+}
+
+func TestRouter_Metrics(t *testing.T) {
+	t.Parallel()
+	public := httpserver.NewModule(http.NewServeMux(), httpserver.Config{Name: "public"})
+	router := NewRouter(Config{Servers: []*httpserver.Module{public}})
+
+	rr := do(router, "GET", "/metrics", "")
+	assertStatus(t, http.StatusOK, rr)
+	body := rr.Body.String()
+	assertContains(t, body, "# TYPE httpserver_in_flight_requests gauge")
+	assertContains(t, body, `httpserver_ready{server="public"} 0`)
+	assertContains(t, body, "go_goroutines ")
+
+	custom := NewRouter(Config{Metrics: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("custom"))
+	})})
+	if rr := do(custom, "GET", "/metrics", ""); rr.Body.String() != "custom" {
+		t.Fatalf("expected custom metrics, got %q", rr.Body.String())
+	}
+}
+
+func TestRouter_DisableDiagnostics(t *testing.T) {
+	t.Parallel()
+	router := NewRouter(Config{DisableDiagnostics: true})
+	assertStatus(t, http.StatusNotFound, do(router, "GET", "/debug/pprof/", ""))
+}
+
+func TestNewModule_Defaults(t *testing.T) {
+	t.Parallel()
+	m := NewModule(Config{})
+	if m.Name() != DefaultName {
+		t.Fatalf("expected name %q, got %q", DefaultName, m.Name())
+	}
+	ctx := context.Background()
+	if err := m.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = m.Stop(ctx) }()
+	if !strings.HasPrefix(m.Addr(), "127.0.0.1:") {
+		t.Fatalf("expected localhost bind, got %s", m.Addr())
+	}
+}
@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+var processStart = time.Now()
+
+// metricsHandler writes server and runtime gauges in the Prometheus
+// text exposition format, without a client library dependency.
+func metricsHandler(servers []*httpserver.Module) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		gauge(w, "httpserver_in_flight_requests", "Requests currently being handled.", servers,
+			func(s httpserver.Stats) float64 { return float64(s.InFlight) })
+		gauge(w, "httpserver_open_connections", "Open client connections, including idle keep-alives.", servers,
+			func(s httpserver.Stats) float64 { return float64(s.Connections) })
+		gauge(w, "httpserver_ready", "1 while the server accepts traffic.", servers,
+			func(s httpserver.Stats) float64 { return boolGauge(s.Ready) })
+		gauge(w, "httpserver_draining", "1 while the server is shutting down.", servers,
+			func(s httpserver.Stats) float64 { return boolGauge(s.Draining) })
+
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+
+		single(w, "go_goroutines", "Number of goroutines.", "gauge", float64(runtime.NumGoroutine()))
+		single(w, "go_memstats_heap_alloc_bytes", "Heap bytes allocated and in use.", "gauge", float64(ms.HeapAlloc))
+		single(w, "go_memstats_sys_bytes", "Bytes obtained from the OS.", "gauge", float64(ms.Sys))
+		single(w, "go_gc_cycles_total", "Completed GC cycles.", "counter", float64(ms.NumGC))
+		single(w, "process_uptime_seconds", "Seconds since the process started.", "gauge", time.Since(processStart).Seconds())
+	}
+}
+
+func gauge(
+	w io.Writer, name, help string, servers []*httpserver.Module,
+	value func(httpserver.Stats) float64,
+) {
+	if len(servers) == 0 {
+		return
+	}
+
+	header(w, name, help, "gauge")
+
+	for _, s := range servers {
+		_, _ = fmt.Fprintf(w, "%s{server=%q} %g\n", name, s.Name(), value(s.Stats()))
+	}
+}
+
+func single(w io.Writer, name, help, typ string, value float64) {
+	header(w, name, help, typ)
+	_, _ = fmt.Fprintf(w, "%s %g\n", name, value)
+}
+
+func header(w io.Writer, name, help, typ string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
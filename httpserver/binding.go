@@ -0,0 +1,280 @@
+package httpserver
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var bindSources = []string{"path", "query", "header", "cookie", "form"}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Source  string `json:"source,omitempty"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Validator interface {
+	Validate() error
+}
+
+// BindRequest fills T from the JSON body (decoded with opts) and from path, query, header,
+// cookie and form values selected by struct tags, then runs the
+// `validate` rules. Conversion and rule failures are reported together
+// as ErrValidation with a "fields" detail.
+func BindRequest[T any](r *http.Request, opts ...BindOption) (T, error) {
+	var target T
+
+	err := bindRequest(r, &target, opts)
+
+	return target, err
+}
+
+func bindRequest(r *http.Request, target any, opts []BindOption) error {
+	v := reflect.ValueOf(target).Elem()
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf(
+			"httpserver: bind target must be a struct, got %s", v.Type(),
+		)
+	}
+
+	var errs []FieldError
+
+	if hasJSONBody(r) {
+		if err := Bind(r, target, opts...); err != nil {
+			fe, ok := jsonTypeError(err)
+			if !ok {
+				return err
+			}
+
+			errs = append(errs, fe)
+		}
+	}
+
+	if isFormContent(r) {
+		if err := r.ParseMultipartForm(DefaultMaxBodySize); err != nil &&
+			err != http.ErrNotMultipart {
+			return fmt.Errorf("httpserver: parse form: %w", err)
+		}
+	}
+
+	bindStruct(r, v, &errs)
+	validateStruct(v, &errs)
+
+	if len(errs) > 0 {
+		return ErrValidation.WithDetail("fields", errs)
+	}
+
+	if val, ok := target.(Validator); ok {
+		return val.Validate()
+	}
+
+	return nil
+}
+
+// jsonTypeError turns a body value of the wrong JSON type into a field
+// error, so it is reported with the other fields.
+func jsonTypeError(err error) (FieldError, bool) {
+	var de *DecodeError
+	if !errors.As(err, &de) || de.Field == "" || de.Expected == "" {
+		return FieldError{}, false
+	}
+
+	return FieldError{
+		Field:   de.Field,
+		Source:  "body",
+		Rule:    "type",
+		Message: de.Reason,
+	}, true
+}
+
+func bindStruct(r *http.Request, v reflect.Value, errs *[]FieldError) {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		fv := v.Field(i)
+
+		if sf.Anonymous && fv.Kind() == reflect.Struct {
+			bindStruct(r, fv, errs)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		for _, source := range bindSources {
+			name, ok := sf.Tag.Lookup(source)
+			if !ok {
+				continue
+			}
+
+			values := sourceValues(r, source, name)
+			if len(values) == 0 {
+				continue
+			}
+
+			if err := setField(fv, values); err != nil {
+				*errs = append(*errs, FieldError{
+					Field:   name,
+					Source:  source,
+					Rule:    "type",
+					Message: err.Error(),
+				})
+			}
+		}
+	}
+}
+
+func sourceValues(r *http.Request, source, name string) []string {
+	switch source {
+	case "path":
+		if v := r.PathValue(name); v != "" {
+			return []string{v}
+		}
+	case "query":
+		return r.URL.Query()[name]
+	case "header":
+		return r.Header.Values(name)
+	case "cookie":
+		if c, err := r.Cookie(name); err == nil {
+			return []string{c.Value}
+		}
+	case "form":
+		if r.MultipartForm != nil {
+			return r.MultipartForm.Value[name]
+		}
+
+		return r.PostForm[name]
+	}
+
+	return nil
+}
+
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() != reflect.Slice || isTextUnmarshaler(fv) {
+		return setScalar(fv, values[0])
+	}
+
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+
+	slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+	for i, s := range values {
+		if err := setScalar(slice.Index(i), strings.TrimSpace(s)); err != nil {
+			return err
+		}
+	}
+
+	fv.Set(slice)
+
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setScalar(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setScalar(ptr.Elem(), s); err != nil {
+			return err
+		}
+
+		fv.Set(ptr)
+
+		return nil
+	}
+
+	if isTextUnmarshaler(fv) {
+		u, _ := fv.Addr().Interface().(encoding.TextUnmarshaler)
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err == nil {
+			fv.SetInt(int64(d))
+		}
+
+		return err
+	}
+
+	return setKind(fv, s)
+}
+
+func setKind(fv reflect.Value, s string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("expected boolean, got %q", s)
+		}
+
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected integer, got %q", s)
+		}
+
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected unsigned integer, got %q", s)
+		}
+
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected number, got %q", s)
+		}
+
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
+
+func isTextUnmarshaler(fv reflect.Value) bool {
+	if !fv.CanAddr() {
+		return false
+	}
+
+	_, ok := fv.Addr().Interface().(encoding.TextUnmarshaler)
+
+	return ok
+}
+
+func hasJSONBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return false
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if _, ok := decoderFor(ct); ok {
+		return true
+	}
+
+	return ct == "" || strings.Contains(ct, "json")
+}
+
+func isFormContent(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+
+	return strings.HasPrefix(ct, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(ct, "multipart/form-data")
+}
@@ -0,0 +1,147 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+type bindInput struct {
+	ID       int           `path:"id"`
+	Page     int           `query:"page"`
+	Tags     []string      `query:"tag"`
+	IDs      []int64       `query:"ids"`
+	Active   bool          `query:"active"`
+	Since    time.Time     `query:"since"`
+	Timeout  time.Duration `query:"timeout"`
+	Limit    *uint         `query:"limit"`
+	Tenant   string        `header:"X-Tenant"`
+	Session  string        `cookie:"session"`
+	Name     string        `json:"name"`
+	Nickname string        `form:"nickname"`
+}
+
+func TestBindRequest_AllSources(t *testing.T) {
+	t.Parallel()
+	var got bindInput
+	var bindErr error
+	router := NewRouter()
+	router.POST("/users/{id}", func(_ http.ResponseWriter, r *http.Request) {
+		got, bindErr = BindRequest[bindInput](r)
+	})
+	target := "/users/7?page=2&tag=a&tag=b&ids=1,2,3&active=true" +
+		"&since=2026-01-02T03:04:05Z&timeout=1m30s&limit=10"
+	req := httptest.NewRequest("POST", target, strings.NewReader(`{"name":"Alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if bindErr != nil {
+		t.Fatalf("unexpected error: %v", bindErr)
+	}
+	if got.ID != 7 || got.Page != 2 || !got.Active || got.Tenant != "acme" || got.Session != "s1" {
+		t.Fatalf("unexpected scalars: %+v", got)
+	}
+	if len(got.Tags) != 2 || len(got.IDs) != 3 || got.IDs[2] != 3 {
+		t.Fatalf("unexpected slices: %v %v", got.Tags, got.IDs)
+	}
+	if got.Since.Year() != 2026 || got.Timeout != 90*time.Second {
+		t.Fatalf("unexpected time values: %v %v", got.Since, got.Timeout)
+	}
+	if got.Limit == nil || *got.Limit != 10 || got.Name != "Alice" {
+		t.Fatalf("unexpected pointer/body values: %+v", got)
+	}
+}
+
+func TestBindRequest_Form(t *testing.T) {
+	t.Parallel()
+	form := url.Values{"nickname": {"ally"}}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	got, err := BindRequest[bindInput](req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Nickname != "ally" {
+		t.Fatalf("expected 'ally', got %q", got.Nickname)
+	}
+}
+
+func TestBindRequest_ConversionErrorsAggregated(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("GET", "/?page=x&active=maybe", nil)
+	_, err := BindRequest[bindInput](req)
+	fields := fieldErrors(t, err)
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 field errors, got %+v", fields)
+	}
+	if fields[0].Field != "page" || fields[0].Source != "query" || fields[0].Rule != "type" {
+		t.Fatalf("unexpected field error: %+v", fields[0])
+	}
+}
+
+func TestBindRequest_RendersAs400(t *testing.T) {
+	t.Parallel()
+	handler := Wrap(func(_ http.ResponseWriter, r *http.Request) error {
+		_, err := BindRequest[bindInput](r)
+		return err
+	})
+	rr := serve(handler, "GET", "/?page=x", nil)
+	assertStatus(t, http.StatusBadRequest, rr)
+	if !strings.Contains(rr.Body.String(), `"field":"page"`) {
+		t.Fatalf("expected field details, got %s", rr.Body.String())
+	}
+}
+
+func TestBindRequest_NonStruct(t *testing.T) {
+	t.Parallel()
+	_, err := BindRequest[int](httptest.NewRequest("GET", "/", nil))
+	if err == nil {
+		t.Fatal("expected error for non-struct target")
+	}
+}
+
+func TestBindRequest_InvalidJSON(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{bad`))
+	_, err := BindRequest[bindInput](req)
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("expected ErrInvalidJSON, got %v", err)
+	}
+}
+
+type customValidated struct {
+	From int `query:"from"`
+	To   int `query:"to"`
+}
+
+func (c customValidated) Validate() error {
+	if c.From > c.To {
+		return errors.New("from must not exceed to")
+	}
+	return nil
+}
+
+func TestBindRequest_ValidatorHook(t *testing.T) {
+	t.Parallel()
+	_, err := BindRequest[customValidated](httptest.NewRequest("GET", "/?from=5&to=1", nil))
+	if err == nil || err.Error() != "from must not exceed to" {
+		t.Fatalf("expected custom validation error, got %v", err)
+	}
+}
+
+func fieldErrors(t *testing.T, err error) []FieldError {
+	t.Helper()
+	var de *domainerrors.Error
+	if !errors.As(err, &de) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+	fields, _ := de.Details()["fields"].([]FieldError)
+	return fields
+}
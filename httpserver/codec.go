@@ -0,0 +1,316 @@
+package httpserver
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, v any) error
+}
+
+type Decoder interface {
+	ContentType() string
+	Decode(r io.Reader, v any) error
+}
+
+// ValueEncoder is an Encoder limited to some kinds of values; Respond
+// only negotiates it for values it reports it can encode.
+type ValueEncoder interface {
+	Encoder
+	CanEncode(v any) bool
+}
+
+type codecRegistry struct {
+	mu       sync.RWMutex
+	encoders []Encoder
+	decoders map[string]Decoder
+}
+
+// Request decoding is JSON-only until decoders are registered.
+var codecs = newCodecRegistry()
+
+func newCodecRegistry() *codecRegistry {
+	return &codecRegistry{
+		encoders: []Encoder{JSONCodec{}, XMLCodec{}, CSVEncoder{}},
+		decoders: make(map[string]Decoder),
+	}
+}
+
+// RegisterEncoder adds or replaces the encoder for its media type.
+// Registration order breaks ties between equally preferred types in
+// Accept, so JSON stays the default for "*/*".
+func RegisterEncoder(enc Encoder) {
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+
+	for i, e := range codecs.encoders {
+		if e.ContentType() == enc.ContentType() {
+			codecs.encoders[i] = enc
+			return
+		}
+	}
+
+	codecs.encoders = append(codecs.encoders, enc)
+}
+
+// RegisterDecoder lets Bind accept bodies of dec's media type and of
+// aliases, e.g. RegisterDecoder(XMLCodec{}, "text/xml").
+func RegisterDecoder(dec Decoder, aliases ...string) {
+	codecs.registerDecoder(dec, aliases...)
+}
+
+func (c *codecRegistry) registerDecoder(dec Decoder, aliases ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, mt := range append([]string{dec.ContentType()}, aliases...) {
+		c.decoders[mt] = dec
+	}
+}
+
+func registeredEncoders() []Encoder {
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+
+	return append([]Encoder(nil), codecs.encoders...)
+}
+
+// decoderFor returns a registered non-JSON decoder for the request
+// Content-Type; JSON and unknown types fall back to the JSON path.
+func decoderFor(contentType string) (Decoder, bool) {
+	return codecs.decoderFor(contentType)
+}
+
+func (c *codecRegistry) decoderFor(contentType string) (Decoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	dec, ok := c.decoders[mediaType]
+
+	return dec, ok
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string { return "application/json" }
+
+func (JSONCodec) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSONCodec) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type XMLCodec struct{}
+
+func (XMLCodec) ContentType() string { return "application/xml" }
+
+func (XMLCodec) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (XMLCodec) Decode(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// CanEncode rejects values encoding/xml cannot represent: nil and
+// maps, channels or functions anywhere in the type.
+func (XMLCodec) CanEncode(v any) bool {
+	if v == nil {
+		return false
+	}
+
+	return xmlEncodable(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+func xmlEncodable(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+
+	seen[t] = true
+
+	if t.Implements(xmlMarshalerType) || reflect.PointerTo(t).Implements(xmlMarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return false
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return xmlEncodable(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !sf.IsExported() || sf.Tag.Get("xml") == "-" {
+				continue
+			}
+
+			if !xmlEncodable(sf.Type, seen) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+var xmlMarshalerType = reflect.TypeFor[xml.Marshaler]()
+
+// CSVEncoder writes a slice of structs as CSV. Column names come from
+// the `csv` tag (falling back to the field name); `csv:"-"` skips a
+// field.
+type CSVEncoder struct{}
+
+func (CSVEncoder) ContentType() string { return "text/csv" }
+
+// CanEncode accepts slices of structs or struct pointers.
+func (CSVEncoder) CanEncode(v any) bool {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice {
+		return false
+	}
+
+	elem := rv.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	return elem.Kind() == reflect.Struct
+}
+
+func (CSVEncoder) Encode(w io.Writer, v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("httpserver: csv: expected slice, got %T", v)
+	}
+
+	elem := rv.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("httpserver: csv: expected slice of structs, got %T", v)
+	}
+
+	columns := csvColumns(elem)
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for i := range rv.Len() {
+		if err := cw.Write(csvRecord(rv.Index(i), columns)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+type csvColumn struct {
+	name  string
+	index int
+}
+
+func csvColumns(t reflect.Type) []csvColumn {
+	columns := make([]csvColumn, 0, t.NumField())
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Tag.Get("csv")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		columns = append(columns, csvColumn{name: name, index: i})
+	}
+
+	return columns
+}
+
+func csvRecord(v reflect.Value, columns []csvColumn) []string {
+	v = reflect.Indirect(v)
+	record := make([]string, len(columns))
+
+	if !v.IsValid() {
+		return record
+	}
+
+	for i, c := range columns {
+		record[i] = csvValue(v.Field(c.index))
+	}
+
+	return record
+}
+
+func csvValue(fv reflect.Value) string {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return ""
+		}
+
+		fv = fv.Elem()
+	}
+
+	switch val := fv.Interface().(type) {
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case encoding.TextMarshaler:
+		b, err := val.MarshalText()
+		if err != nil {
+			return ""
+		}
+
+		return string(b)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func joinMediaTypes(encs []Encoder) string {
+	types := make([]string, len(encs))
+	for i, e := range encs {
+		types[i] = e.ContentType()
+	}
+
+	return strings.Join(types, ", ")
+}
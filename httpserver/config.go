@@ -1,16 +1,36 @@
 package httpserver
 
-import "time"
+import (
+	"net"
+	"strconv"
+	"time"
+)
+
+const DefaultModuleName = "httpserver"
 
 type Config struct {
-	Host         string
-	Port         int
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	IdleTimeout  time.Duration
+	Name           string // module name; "" = "httpserver"
+	Host           string
+	Port           int
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	MaxHeaderBytes int        // 0 = net/http default (1 MB)
+	TLS            *TLSConfig // nil = plain HTTP
+	H2C            bool       // HTTP/2 with prior knowledge on plain listeners
+	// Listeners overrides Host/Port; every entry is served by the
+	// same http.Server.
+	Listeners []ListenerConfig
+	Handoff   ListenerHandoff // nil = always open fresh sockets
+	Shutdown  ShutdownConfig
+	Logger    Logger // shutdown progress; nil = silent
 }
 
 func (c Config) withDefaults() Config {
+	if c.Name == "" {
+		c.Name = DefaultModuleName
+	}
+
 	if c.ReadTimeout == 0 {
 		c.ReadTimeout = 15 * time.Second
 	}
@@ -23,5 +43,20 @@ func (c Config) withDefaults() Config {
 		c.IdleTimeout = 60 * time.Second
 	}
 
+	if c.Shutdown.ProgressInterval == 0 {
+		c.Shutdown.ProgressInterval = DefaultDrainProgressInterval
+	}
+
 	return c
 }
+
+func (c Config) listeners() []ListenerConfig {
+	if len(c.Listeners) > 0 {
+		return c.Listeners
+	}
+
+	return []ListenerConfig{{
+		Network: NetworkTCP,
+		Address: net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+	}}
+}
@@ -0,0 +1,261 @@
+package diagnostics
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+const (
+	DefaultPrefix      = "/debug"
+	DefaultMaxDuration = time.Minute
+)
+
+var ErrForbidden = domainerrors.NewCode("DIAGNOSTICS_FORBIDDEN").
+	Kind(domainerrors.Authorization).
+	New("diagnostics are only available from localhost")
+
+var ErrTraceActive = domainerrors.NewCode("DIAGNOSTICS_TRACE_ACTIVE").
+	Kind(domainerrors.Conflict).
+	New("a runtime trace is already being captured")
+
+type Config struct {
+	// Prefix of every endpoint; "" = DefaultPrefix.
+	Prefix string
+	// Guard protects the endpoints; nil = LocalOnly. Use Unguarded when
+	// the enclosing router already authenticates.
+	Guard httpserver.Middleware
+	// MaxDuration caps ?seconds= of CPU profiles and traces; 0 =
+	// DefaultMaxDuration.
+	MaxDuration time.Duration
+	// Version is reported by buildinfo next to the module build info.
+	Version string
+}
+
+// Mount registers the diagnostics endpoints on router:
+//
+//	{prefix}/pprof/...        net/http/pprof
+//	{prefix}/goroutines       full goroutine dump (text)
+//	{prefix}/memstats         runtime.MemStats and GC stats (JSON)
+//	{prefix}/trace?seconds=N  runtime/trace capture
+//	{prefix}/buildinfo        debug.ReadBuildInfo, VCS revision (JSON)
+func Mount(router *httpserver.Router, cfg Config) {
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+
+	if cfg.Guard == nil {
+		cfg.Guard = LocalOnly
+	}
+
+	if cfg.MaxDuration == 0 {
+		cfg.MaxDuration = DefaultMaxDuration
+	}
+
+	g := router.Group(cfg.Prefix, cfg.Guard)
+	hidden := httpserver.WithHidden()
+
+	// pprof.Index serves named profiles only under /debug/pprof/, so
+	// they get their own route to work with any prefix.
+	g.GET("/pprof/", pprof.Index, hidden)
+	g.GET("/pprof/{name}", capSeconds(cfg.MaxDuration, namedProfile), hidden)
+	g.GET("/pprof/cmdline", pprof.Cmdline, hidden)
+	g.GET("/pprof/profile", capSeconds(cfg.MaxDuration, pprof.Profile), hidden)
+	g.GET("/pprof/symbol", pprof.Symbol, hidden)
+	g.POST("/pprof/symbol", pprof.Symbol, hidden)
+	g.GET("/pprof/trace", capSeconds(cfg.MaxDuration, pprof.Trace), hidden)
+	g.GET("/goroutines", goroutines, hidden)
+	g.GET("/memstats", memStats, hidden)
+	g.GET("/trace", traceHandler(cfg.MaxDuration), hidden)
+	g.GET("/buildinfo", BuildInfoHandler(cfg.Version), hidden)
+}
+
+// LocalOnly lets through requests from loopback addresses only.
+func LocalOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		// Unix socket peers have no IP and are local by definition.
+		if ip := net.ParseIP(host); ip != nil && !ip.IsLoopback() {
+			httpserver.Error(w, ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Unguarded is a no-op Guard.
+func Unguarded(next http.Handler) http.Handler { return next }
+
+func seconds(r *http.Request, def, limit time.Duration) time.Duration {
+	d := def
+	if n, err := strconv.ParseFloat(r.URL.Query().Get("seconds"), 64); err == nil && n > 0 {
+		d = time.Duration(n * float64(time.Second))
+	}
+
+	return min(d, limit)
+}
+
+// namedProfile serves runtime/pprof profiles such as heap or goroutine.
+func namedProfile(w http.ResponseWriter, r *http.Request) {
+	pprof.Handler(r.PathValue("name")).ServeHTTP(w, r)
+}
+
+// capSeconds rewrites ?seconds= so pprof handlers cannot be asked to
+// run longer than limit.
+func capSeconds(limit time.Duration, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("seconds") {
+			d := seconds(r, limit, limit)
+			q := r.URL.Query()
+			q.Set("seconds", strconv.Itoa(max(1, int(d.Seconds()))))
+			r.URL.RawQuery = q.Encode()
+		}
+
+		extendWriteDeadline(w, limit)
+		h(w, r)
+	}
+}
+
+// extendWriteDeadline keeps Config.WriteTimeout from cutting a capture
+// short.
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d + 10*time.Second))
+}
+
+func goroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+type memStatsBody struct {
+	Goroutines   int       `json:"goroutines"`
+	HeapAlloc    uint64    `json:"heap_alloc_bytes"`
+	HeapInuse    uint64    `json:"heap_inuse_bytes"`
+	HeapObjects  uint64    `json:"heap_objects"`
+	StackInuse   uint64    `json:"stack_inuse_bytes"`
+	Sys          uint64    `json:"sys_bytes"`
+	TotalAlloc   uint64    `json:"total_alloc_bytes"`
+	Mallocs      uint64    `json:"mallocs"`
+	Frees        uint64    `json:"frees"`
+	NumGC        uint32    `json:"num_gc"`
+	PauseTotal   string    `json:"gc_pause_total"`
+	LastGC       time.Time `json:"last_gc"`
+	NextGC       uint64    `json:"next_gc_bytes"`
+	GCCPUPercent float64   `json:"gc_cpu_percent"`
+	GOMAXPROCS   int       `json:"gomaxprocs"`
+}
+
+func memStats(w http.ResponseWriter, _ *http.Request) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+
+	httpserver.OK(w, memStatsBody{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    ms.HeapAlloc,
+		HeapInuse:    ms.HeapInuse,
+		HeapObjects:  ms.HeapObjects,
+		StackInuse:   ms.StackInuse,
+		Sys:          ms.Sys,
+		TotalAlloc:   ms.TotalAlloc,
+		Mallocs:      ms.Mallocs,
+		Frees:        ms.Frees,
+		NumGC:        ms.NumGC,
+		PauseTotal:   gc.PauseTotal.String(),
+		LastGC:       gc.LastGC,
+		NextGC:       ms.NextGC,
+		GCCPUPercent: ms.GCCPUFraction * 100,
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+	})
+}
+
+func traceHandler(limit time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d := seconds(r, time.Second, limit)
+		extendWriteDeadline(w, d)
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="trace.out"`)
+
+		if err := trace.Start(w); err != nil {
+			w.Header().Del("Content-Disposition")
+			httpserver.Error(w, ErrTraceActive.WithCause(err))
+
+			return
+		}
+
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+		}
+
+		trace.Stop()
+	}
+}
+
+type BuildInfo struct {
+	Version     string            `json:"version,omitempty"`
+	GoVersion   string            `json:"go_version"`
+	Path        string            `json:"path,omitempty"`
+	Module      string            `json:"module_version,omitempty"`
+	VCSRevision string            `json:"vcs_revision,omitempty"`
+	VCSTime     string            `json:"vcs_time,omitempty"`
+	VCSModified bool              `json:"vcs_modified,omitempty"`
+	Settings    map[string]string `json:"settings,omitempty"`
+}
+
+// ReadBuildInfo collects debug.ReadBuildInfo into a flat structure.
+func ReadBuildInfo(version string) BuildInfo {
+	info := BuildInfo{Version: version, GoVersion: runtime.Version()}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = bi.GoVersion
+	info.Path = bi.Path
+	info.Module = bi.Main.Version
+	info.Settings = make(map[string]string, len(bi.Settings))
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.VCSRevision = s.Value
+		case "vcs.time":
+			info.VCSTime = s.Value
+		case "vcs.modified":
+			info.VCSModified = s.Value == "true"
+		case "vcs", "GOOS", "GOARCH", "CGO_ENABLED", "-compiler", "-race", "-tags":
+			// -ldflags is left out: -X values may carry secrets.
+			info.Settings[s.Key] = s.Value
+		}
+	}
+
+	return info
+}
+
+func BuildInfoHandler(version string) http.HandlerFunc {
+	info := ReadBuildInfo(version)
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		httpserver.OK(w, info)
+	}
+}
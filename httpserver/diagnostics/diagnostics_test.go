@@ -0,0 +1,139 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func serve(h http.Handler, path, remote string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if remote != "" {
+		req.RemoteAddr = remote
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func mounted(cfg Config) *httpserver.Router {
+	router := httpserver.NewRouter()
+	Mount(router, cfg)
+
+	return router
+}
+
+func TestMount_LocalOnlyByDefault(t *testing.T) {
+	t.Parallel()
+	router := mounted(Config{})
+
+	rr := serve(router, "/debug/memstats", "10.0.0.5:4000")
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for remote client, got %d", rr.Code)
+	}
+
+	rr = serve(router, "/debug/memstats", "127.0.0.1:4000")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for loopback, got %d", rr.Code)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["goroutines"].(float64) < 1 || body["heap_alloc_bytes"] == nil {
+		t.Fatalf("unexpected memstats %v", body)
+	}
+}
+
+func TestMount_CustomPrefixAndGuard(t *testing.T) {
+	t.Parallel()
+	guarded := false
+	router := mounted(Config{
+		Prefix: "/_diag",
+		Guard: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				guarded = true
+				next.ServeHTTP(w, r)
+			})
+		},
+		Version: "1.2.3",
+	})
+
+	rr := serve(router, "/_diag/buildinfo", "10.0.0.5:4000")
+	if rr.Code != http.StatusOK || !guarded {
+		t.Fatalf("expected guarded 200, got %d", rr.Code)
+	}
+	var info BuildInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "1.2.3" || info.GoVersion == "" {
+		t.Fatalf("unexpected build info %+v", info)
+	}
+}
+
+func TestGoroutineDumpAndPprof(t *testing.T) {
+	t.Parallel()
+	router := mounted(Config{Guard: Unguarded})
+
+	rr := serve(router, "/debug/goroutines", "")
+	if !strings.Contains(rr.Body.String(), "goroutine ") {
+		t.Fatalf("expected goroutine dump, got %q", rr.Body.String())
+	}
+
+	rr = serve(router, "/debug/pprof/heap", "")
+	if rr.Code != http.StatusOK || rr.Body.Len() == 0 {
+		t.Fatalf("expected heap profile, got %d", rr.Code)
+	}
+}
+
+func TestPprof_NamedProfileUnderCustomPrefix(t *testing.T) {
+	t.Parallel()
+	router := mounted(Config{Prefix: "/diag", Guard: Unguarded})
+
+	rr := serve(router, "/diag/pprof/heap", "")
+	if rr.Code != http.StatusOK || strings.Contains(rr.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected heap profile, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	rr = serve(router, "/diag/pprof/goroutine?debug=1", "")
+	if !strings.Contains(rr.Body.String(), "goroutine profile:") {
+		t.Fatalf("expected goroutine profile, got %q", rr.Body.String())
+	}
+
+	if rr = serve(router, "/diag/pprof/nope", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown profile, got %d", rr.Code)
+	}
+}
+
+func TestTrace_CapturesForDuration(t *testing.T) {
+	router := mounted(Config{Guard: Unguarded, MaxDuration: 100 * time.Millisecond})
+
+	start := time.Now()
+	rr := serve(router, "/debug/trace?seconds=10", "")
+	if rr.Code != http.StatusOK || rr.Body.Len() == 0 {
+		t.Fatalf("expected trace output, got %d", rr.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("trace not capped by MaxDuration: %v", elapsed)
+	}
+}
+
+func TestCapSeconds(t *testing.T) {
+	t.Parallel()
+	var got string
+	h := capSeconds(5*time.Second, func(_ http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("seconds")
+	})
+	serve(h, "/?seconds=600", "")
+	if got != "5" {
+		t.Fatalf("expected seconds capped to 5, got %q", got)
+	}
+}
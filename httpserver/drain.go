@@ -0,0 +1,207 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+const DefaultDrainProgressInterval = time.Second
+
+var ErrNotReady = domainerrors.NewCode("SERVER_NOT_READY").
+	Kind(domainerrors.Infrastructure).
+	New("server is not ready to accept traffic")
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Info(string, ...any)  {}
+func (noopLogger) Error(string, ...any) {}
+
+// ShutdownConfig controls the staged Stop sequence: mark not ready,
+// wait DeregistrationDelay, stop accepting and drain, force-close
+// whatever is left at the deadline.
+type ShutdownConfig struct {
+	// DeregistrationDelay keeps serving after readiness turns off so
+	// load balancers stop routing here; 0 = no delay.
+	DeregistrationDelay time.Duration
+	// DrainTimeout bounds the drain; 0 = until the Stop context ends.
+	DrainTimeout time.Duration
+	// ProgressInterval between "draining" log lines; 0 =
+	// DefaultDrainProgressInterval.
+	ProgressInterval time.Duration
+}
+
+// Stats is a snapshot of server load, suitable for metrics.
+type Stats struct {
+	InFlight    int64 `json:"in_flight"`   // requests inside the handler
+	Connections int64 `json:"connections"` // open, including idle keep-alives
+	Ready       bool  `json:"ready"`
+	Draining    bool  `json:"draining"`
+}
+
+type drainKey struct{}
+
+// Draining returns a channel closed when the server starts draining,
+// so long-lived handlers (streams, polls) can finish early. It is nil
+// outside a Module.
+func Draining(ctx context.Context) <-chan struct{} {
+	ch, _ := ctx.Value(drainKey{}).(chan struct{})
+	return ch
+}
+
+type tracker struct {
+	inFlight    atomic.Int64
+	connections atomic.Int64
+	ready       atomic.Bool
+	draining    atomic.Bool
+	drainCh     chan struct{}
+}
+
+func newTracker() *tracker {
+	return &tracker{drainCh: make(chan struct{})}
+}
+
+func (t *tracker) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.inFlight.Add(1)
+		defer t.inFlight.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *tracker) connState(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		t.connections.Add(1)
+	case http.StateClosed, http.StateHijacked:
+		t.connections.Add(-1)
+	}
+}
+
+func (t *tracker) baseContext(net.Listener) context.Context {
+	return context.WithValue(context.Background(), drainKey{}, t.drainCh)
+}
+
+func (m *Module) Stats() Stats {
+	return Stats{
+		InFlight:    m.track.inFlight.Load(),
+		Connections: m.track.connections.Load(),
+		Ready:       m.Ready(),
+		Draining:    m.track.draining.Load(),
+	}
+}
+
+// Ready reports whether the server is started and not shutting down.
+func (m *Module) Ready() bool {
+	return m.track.ready.Load() && !m.track.draining.Load()
+}
+
+// Health implements app.HealthChecker.
+func (m *Module) Health(_ context.Context) error {
+	if !m.Ready() {
+		return ErrNotReady
+	}
+
+	return nil
+}
+
+// ReadinessHandler answers 200 while Ready and 503 otherwise; point the
+// load balancer's readiness probe at it.
+func (m *Module) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if !m.Ready() {
+			Error(w, ErrNotReady)
+			return
+		}
+
+		JSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	}
+}
+
+func (m *Module) drain(ctx context.Context) error {
+	cfg := m.cfg.Shutdown
+
+	if !m.track.draining.CompareAndSwap(false, true) {
+		return m.server.Shutdown(ctx)
+	}
+
+	// Connection: close on every response so keep-alive clients move
+	// to other instances.
+	m.server.SetKeepAlivesEnabled(false)
+	m.log.Info("httpserver: not ready, waiting for deregistration",
+		"delay", cfg.DeregistrationDelay,
+	)
+
+	if !sleepCtx(ctx, cfg.DeregistrationDelay) {
+		return m.forceClose(ctx.Err())
+	}
+
+	close(m.track.drainCh)
+
+	if cfg.DrainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.DrainTimeout)
+
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.server.Shutdown(ctx) }()
+
+	ticker := time.NewTicker(cfg.ProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return m.forceClose(err)
+			}
+
+			m.log.Info("httpserver: drained")
+
+			return nil
+		case <-ticker.C:
+			m.log.Info("httpserver: draining",
+				"in_flight", m.track.inFlight.Load(),
+				"connections", m.track.connections.Load(),
+			)
+		}
+	}
+}
+
+func (m *Module) forceClose(cause error) error {
+	m.log.Error("httpserver: drain deadline exceeded, closing connections",
+		"in_flight", m.track.inFlight.Load(),
+		"connections", m.track.connections.Load(),
+	)
+
+	return errors.Join(cause, m.server.Close())
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
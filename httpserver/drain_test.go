@@ -0,0 +1,171 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) Info(msg string, args ...any)  { l.add(msg, args) }
+func (l *recordLogger) Error(msg string, args ...any) { l.add(msg, args) }
+
+func (l *recordLogger) add(msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf("%s %v", msg, args))
+}
+
+func (l *recordLogger) contains(s string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func startModule(t *testing.T, h http.Handler, cfg Config) *Module {
+	t.Helper()
+	cfg.Host = "127.0.0.1"
+	m := NewModule(h, cfg)
+	assertNoErr(t, m.Init(context.Background()))
+	assertNoErr(t, m.Start(context.Background()))
+
+	return m
+}
+
+func TestModule_Readiness_DeregistrationDelay(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	m := startModule(t, router, Config{Shutdown: ShutdownConfig{DeregistrationDelay: 200 * time.Millisecond}})
+	router.GET("/readyz", m.ReadinessHandler())
+	router.GET("/ping", ok)
+
+	client := &http.Client{Timeout: time.Second}
+	resp := httpGet(t, "http://"+m.Addr()+"/readyz")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !m.Stats().Ready {
+		t.Fatalf("expected ready, got %d", resp.StatusCode)
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- m.Stop(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+
+	resp, err := client.Get("http://" + m.Addr() + "/readyz")
+	assertNoErr(t, err)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while deregistering, got %d", resp.StatusCode)
+	}
+	if m.Health(context.Background()) == nil {
+		t.Fatal("expected Health error while draining")
+	}
+
+	resp, err = client.Get("http://" + m.Addr() + "/ping")
+	assertNoErr(t, err)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !resp.Close {
+		t.Fatalf("expected request served with Connection: close, got %d close=%v", resp.StatusCode, resp.Close)
+	}
+
+	assertNoErr(t, <-stopped)
+}
+
+func TestModule_DrainsInFlight(t *testing.T) {
+	t.Parallel()
+	entered, release := make(chan struct{}), make(chan struct{})
+	log := &recordLogger{}
+	m := startModule(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(entered)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	}), Config{Logger: log, Shutdown: ShutdownConfig{ProgressInterval: 20 * time.Millisecond}})
+
+	result := make(chan error, 1)
+	go func() {
+		resp, err := (&http.Client{Timeout: 2 * time.Second}).Get("http://" + m.Addr() + "/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		result <- err
+	}()
+	<-entered
+
+	if s := m.Stats(); s.InFlight != 1 || s.Connections != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- m.Stop(context.Background()) }()
+	time.Sleep(80 * time.Millisecond)
+	close(release)
+
+	assertNoErr(t, <-stopped)
+	assertNoErr(t, <-result)
+	if !log.contains("in_flight 1") || !log.contains("httpserver: drained") {
+		t.Fatalf("expected progress logging, got %v", log.lines)
+	}
+	if s := m.Stats(); s.InFlight != 0 || !s.Draining || s.Ready {
+		t.Fatalf("unexpected stats after stop %+v", s)
+	}
+}
+
+func TestModule_ForceClosesAfterDrainTimeout(t *testing.T) {
+	t.Parallel()
+	entered := make(chan struct{})
+	m := startModule(t, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-r.Context().Done()
+	}), Config{Shutdown: ShutdownConfig{DrainTimeout: 50 * time.Millisecond}})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := (&http.Client{Timeout: 2 * time.Second}).Get("http://" + m.Addr() + "/")
+		result <- err
+	}()
+	<-entered
+
+	err := m.Stop(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if <-result == nil {
+		t.Fatal("expected straggler connection to be closed")
+	}
+}
+
+func TestModule_DrainEndsSSE(t *testing.T) {
+	t.Parallel()
+	m := startModule(t, SSE(SSEConfig{}, func(ctx context.Context, s *SSEStream) error {
+		_ = s.Send(Event{Data: "hello"})
+		<-ctx.Done()
+		return nil
+	}), Config{})
+
+	resp, err := (&http.Client{Timeout: 2 * time.Second}).Get("http://" + m.Addr() + "/")
+	assertNoErr(t, err)
+	defer resp.Body.Close()
+	line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	if line != "data: hello\n" {
+		t.Fatalf("unexpected first line %q", line)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assertNoErr(t, m.Stop(ctx))
+}
@@ -1,9 +1,86 @@
 package httpserver
 
-import "errors"
+import (
+	"net/http"
 
-var (
-	ErrEmptyBody    = errors.New("request body is empty")
-	ErrBodyTooLarge = errors.New("request body too large")
-	ErrInvalidJSON  = errors.New("invalid JSON")
+	domainerrors "github.com/shuldan/errors"
 )
+
+var ErrEmptyBody = domainerrors.NewCode("EMPTY_BODY").
+	Kind(domainerrors.Validation).
+	New("request body is empty")
+
+var ErrBodyTooLarge = domainerrors.NewCode("BODY_TOO_LARGE").
+	Kind(domainerrors.Validation).
+	New("request body too large")
+
+var ErrInvalidJSON = domainerrors.NewCode("INVALID_JSON").
+	Kind(domainerrors.Validation).
+	New("invalid JSON")
+
+var ErrUnsupportedMediaType = domainerrors.NewCode("UNSUPPORTED_MEDIA_TYPE").
+	Kind(domainerrors.Validation).
+	New("unsupported media type")
+
+var ErrInvalidBody = domainerrors.NewCode("INVALID_BODY").
+	Kind(domainerrors.Validation).
+	New("request body cannot be decoded")
+
+var ErrNotAcceptable = domainerrors.NewCode("NOT_ACCEPTABLE").
+	Kind(domainerrors.Validation).
+	New("none of the acceptable media types can be produced")
+
+var ErrRouteNotFound = domainerrors.NewCode("ROUTE_NOT_FOUND").
+	Kind(domainerrors.NotFound).
+	New("route not found")
+
+var ErrMethodNotAllowed = domainerrors.NewCode("METHOD_NOT_ALLOWED").
+	Kind(domainerrors.Validation).
+	New("method not allowed")
+
+var ErrInternal = domainerrors.NewCode("INTERNAL").
+	Kind(domainerrors.Internal).
+	New("internal error")
+
+var ErrValidation = domainerrors.NewCode("VALIDATION_FAILED").
+	Kind(domainerrors.Validation).
+	New("request validation failed")
+
+var ErrInvalidCursor = domainerrors.NewCode("INVALID_CURSOR").
+	Kind(domainerrors.Validation).
+	New("pagination cursor is invalid")
+
+var ErrFileTooLarge = domainerrors.NewCode("FILE_TOO_LARGE").
+	Kind(domainerrors.Validation).
+	New("uploaded file too large")
+
+var ErrTooManyFiles = domainerrors.NewCode("TOO_MANY_FILES").
+	Kind(domainerrors.Validation).
+	New("too many uploaded files")
+
+var ErrUnsupportedFileType = domainerrors.NewCode("UNSUPPORTED_FILE_TYPE").
+	Kind(domainerrors.Validation).
+	New("uploaded file type is not allowed")
+
+var ErrFileNotFound = domainerrors.NewCode("FILE_NOT_FOUND").
+	Kind(domainerrors.NotFound).
+	New("file not found")
+
+// errorStatus refines the Kind-based status for codes whose HTTP
+// meaning is more specific than the kind (405, 406, 413, 415).
+var errorStatus = map[domainerrors.Code]int{
+	ErrBodyTooLarge.GetCode():         http.StatusRequestEntityTooLarge,
+	ErrFileTooLarge.GetCode():         http.StatusRequestEntityTooLarge,
+	ErrUnsupportedFileType.GetCode():  http.StatusUnsupportedMediaType,
+	ErrUnsupportedMediaType.GetCode(): http.StatusUnsupportedMediaType,
+	ErrNotAcceptable.GetCode():        http.StatusNotAcceptable,
+	ErrMethodNotAllowed.GetCode():     http.StatusMethodNotAllowed,
+}
+
+func errorHTTPStatus(err error) int {
+	if status, ok := errorStatus[domainerrors.GetCode(err)]; ok {
+		return status
+	}
+
+	return domainerrors.ToHTTPStatus(err)
+}
@@ -0,0 +1,177 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"time"
+)
+
+// FileOptions shape a File or Stream response.
+type FileOptions struct {
+	// Name is the download name for Content-Disposition and the
+	// extension used to guess Content-Type.
+	Name        string
+	Inline      bool   // Content-Disposition: inline instead of attachment
+	ContentType string // "" = by extension of Name, then sniffed
+	ModTime     time.Time
+	// SHA256 is a known hex digest, e.g. UploadedFile.SHA256; it
+	// becomes a strong ETag and a Repr-Digest header (RFC 9530).
+	SHA256 string
+	// Checksum computes SHA256 when it is not given. Seekable content
+	// is read twice; a plain io.Reader gets the digest as a trailer.
+	Checksum bool
+}
+
+// File serves the file at path with Range, If-Range and conditional
+// request support. A missing file or a directory is ErrFileNotFound.
+func File(w http.ResponseWriter, r *http.Request, path string, opts FileOptions) {
+	f, err := os.Open(path)
+	if err != nil {
+		fileError(w, err)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		fileError(w, err)
+		return
+	}
+
+	if fi.IsDir() {
+		Error(w, ErrFileNotFound)
+		return
+	}
+
+	if opts.ModTime.IsZero() {
+		opts.ModTime = fi.ModTime()
+	}
+
+	if opts.Name == "" {
+		opts.Name = fi.Name()
+	}
+
+	Stream(w, r, f, opts)
+}
+
+// Stream serves content. An io.ReadSeeker gets Range and conditional
+// request handling through http.ServeContent; any other reader is
+// copied as is with Accept-Ranges: none.
+func Stream(w http.ResponseWriter, r *http.Request, content io.Reader, opts FileOptions) {
+	h := w.Header()
+
+	if opts.Name != "" {
+		disposition := "attachment"
+		if opts.Inline {
+			disposition = "inline"
+		}
+
+		h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": opts.Name}))
+	}
+
+	if opts.ContentType != "" {
+		h.Set("Content-Type", opts.ContentType)
+	}
+
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	if rs, ok := content.(io.ReadSeeker); ok {
+		serveSeeker(w, r, rs, opts)
+		return
+	}
+
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", contentTypeByName(opts.Name))
+	}
+
+	if !opts.ModTime.IsZero() {
+		h.Set("Last-Modified", opts.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	h.Set("Accept-Ranges", "none")
+
+	if opts.SHA256 != "" {
+		setDigest(h, opts.SHA256)
+	}
+
+	hash := sha256.New()
+	if opts.SHA256 == "" && opts.Checksum {
+		h.Set("Trailer", "Repr-Digest")
+		content = io.TeeReader(content, hash)
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if _, err := io.Copy(w, content); err != nil {
+		return
+	}
+
+	if opts.SHA256 == "" && opts.Checksum {
+		h.Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(hash.Sum(nil))+":")
+	}
+}
+
+func serveSeeker(w http.ResponseWriter, r *http.Request, rs io.ReadSeeker, opts FileOptions) {
+	sum := opts.SHA256
+
+	if sum == "" && opts.Checksum {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, rs); err != nil {
+			Error(w, ErrInternal.WithCause(err))
+			return
+		}
+
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			Error(w, ErrInternal.WithCause(err))
+			return
+		}
+
+		sum = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	if sum != "" {
+		setDigest(w.Header(), sum)
+	}
+
+	// ServeContent falls back to sniffing when the name has no known
+	// extension.
+	http.ServeContent(w, r, opts.Name, opts.ModTime, rs)
+}
+
+// setDigest sets a strong ETag and Repr-Digest from a hex SHA-256.
+func setDigest(h http.Header, sum string) {
+	h.Set("ETag", `"`+sum+`"`)
+
+	if raw, err := hex.DecodeString(sum); err == nil {
+		h.Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(raw)+":")
+	}
+}
+
+func contentTypeByName(name string) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+
+	return "application/octet-stream"
+}
+
+func fileError(w http.ResponseWriter, err error) {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		Error(w, ErrFileNotFound)
+		return
+	}
+
+	Error(w, ErrInternal.WithCause(err))
+}
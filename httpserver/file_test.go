@@ -0,0 +1,114 @@
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFile_RangeAndDisposition(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "report.txt")
+	assertNoErr(t, os.WriteFile(path, []byte("0123456789"), 0o600))
+
+	r := httptest.NewRequest("GET", "/download", nil)
+	r.Header.Set("Range", "bytes=2-5")
+	rr := httptest.NewRecorder()
+	File(rr, r, path, FileOptions{Name: "отчёт.txt", Checksum: true})
+
+	assertStatus(t, http.StatusPartialContent, rr)
+	assertBody(t, "2345", rr)
+	assertHeader(t, "Content-Range", "bytes 2-5/10", rr)
+	assertContains(t, rr.Header().Get("Content-Disposition"), "attachment; filename*=utf-8''")
+	assertContains(t, rr.Header().Get("Content-Type"), "text/plain")
+	assertContains(t, rr.Header().Get("Repr-Digest"), "sha-256=:")
+
+	etag := rr.Header().Get("ETag")
+	r = httptest.NewRequest("GET", "/download", nil)
+	r.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	File(rr, r, path, FileOptions{Checksum: true})
+	assertStatus(t, http.StatusNotModified, rr)
+}
+
+func TestFile_NotFound(t *testing.T) {
+	t.Parallel()
+	for _, path := range []string{filepath.Join(t.TempDir(), "missing"), t.TempDir()} {
+		rr := httptest.NewRecorder()
+		File(rr, httptest.NewRequest("GET", "/", nil), path, FileOptions{})
+		assertStatus(t, http.StatusNotFound, rr)
+		assertContains(t, rr.Body.String(), "FILE_NOT_FOUND")
+	}
+}
+
+func TestStream_PlainReaderWithTrailer(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Stream(w, r, io.MultiReader(strings.NewReader("a,b\n")), FileOptions{
+			Name: "export.csv", Inline: true, Checksum: true,
+		})
+	}))
+	defer srv.Close()
+
+	resp := httpGet(t, srv.URL)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "a,b\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if resp.Header.Get("Accept-Ranges") != "none" ||
+		!strings.HasPrefix(resp.Header.Get("Content-Disposition"), "inline") ||
+		!strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected headers: %v", resp.Header)
+	}
+	if !strings.HasPrefix(resp.Trailer.Get("Repr-Digest"), "sha-256=:") {
+		t.Fatalf("expected digest trailer, got %v", resp.Trailer)
+	}
+}
+
+var staticFS = fstest.MapFS{
+	"index.html":        {Data: []byte("<html>app</html>")},
+	"assets/app.1a2.js": {Data: []byte("console.log(1)")},
+	"robots.txt":        {Data: []byte("User-agent: *")},
+	"docs/index.html":   {Data: []byte("docs")},
+}
+
+func TestStatic_CacheHeadersAndETag(t *testing.T) {
+	t.Parallel()
+	h := Static(StaticConfig{FS: staticFS, Immutable: []string{"assets/*"}})
+
+	rr := serve(h, "GET", "/assets/app.1a2.js", nil)
+	assertStatus(t, http.StatusOK, rr)
+	assertHeader(t, "Cache-Control", ImmutableCacheControl, rr)
+	assertContains(t, rr.Header().Get("Content-Type"), "javascript")
+
+	assertHeader(t, "Cache-Control", DefaultStaticCacheControl, serve(h, "GET", "/robots.txt", nil))
+
+	rr = serve(h, "GET", "/", nil)
+	assertBody(t, "<html>app</html>", rr)
+	assertHeader(t, "Cache-Control", DefaultIndexCacheControl, rr)
+	assertBody(t, "docs", serve(h, "GET", "/docs/", nil))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+	assertStatus(t, http.StatusNotModified, rr)
+}
+
+func TestStatic_SPAFallback(t *testing.T) {
+	t.Parallel()
+	spa := Static(StaticConfig{FS: staticFS, SPA: true})
+	assertBody(t, "<html>app</html>", serve(spa, "GET", "/orders/42", nil))
+	assertStatus(t, http.StatusNotFound, serve(spa, "GET", "/assets/missing.js", nil))
+	assertStatus(t, http.StatusNotFound, serve(spa, "GET", "/../../etc/passwd.txt", nil))
+	assertStatus(t, http.StatusMethodNotAllowed, serve(spa, "POST", "/", nil))
+
+	plain := Static(StaticConfig{FS: staticFS})
+	assertStatus(t, http.StatusNotFound, serve(plain, "GET", "/orders/42", nil))
+}
@@ -0,0 +1,154 @@
+package httpcache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const DefaultMaxEntries = 1000
+
+type entry struct {
+	key       string
+	status    int
+	header    http.Header
+	body      []byte
+	tags      []string
+	storedAt  time.Time
+	expiresAt time.Time
+}
+
+// Cache is an in-memory LRU of responses with tag-based invalidation.
+// One Cache can back several routes.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	entries    map[string]*list.Element
+	tags       map[string]map[string]struct{}
+	vary       map[string][]string
+	now        func() time.Time
+}
+
+// NewCache keeps at most maxEntries responses; 0 = DefaultMaxEntries.
+func NewCache(maxEntries int) *Cache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &Cache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+		vary:       make(map[string][]string),
+		now:        time.Now,
+	}
+}
+
+// InvalidateTags drops every response tagged with any of tags and
+// returns how many were removed. Call it from domain event handlers
+// when the underlying data changes.
+func (c *Cache) InvalidateTags(tags ...string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.entries[key]; ok {
+				c.remove(el)
+				removed++
+			}
+		}
+	}
+
+	return removed
+}
+
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	clear(c.entries)
+	clear(c.tags)
+	clear(c.vary)
+}
+
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// varyHeaders returns the Vary header names last seen for base.
+func (c *Cache) varyHeaders(base string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.vary[base]
+}
+
+func (c *Cache) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+
+	return e, true
+}
+
+func (c *Cache) set(base string, vary []string, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.vary[base] = vary
+
+	if el, ok := c.entries[e.key]; ok {
+		c.remove(el)
+	}
+
+	c.entries[e.key] = c.lru.PushFront(e)
+
+	for _, tag := range e.tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+
+		c.tags[tag][e.key] = struct{}{}
+	}
+
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+
+	c.lru.Remove(el)
+	delete(c.entries, e.key)
+
+	for _, tag := range e.tags {
+		delete(c.tags[tag], e.key)
+
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
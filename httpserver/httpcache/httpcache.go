@@ -0,0 +1,351 @@
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderCache = "X-Cache"
+
+	DefaultTTL = time.Minute
+)
+
+type Config struct {
+	// CacheControl is set on responses that don't set their own, e.g.
+	// "public, max-age=60".
+	CacheControl string
+	// Cache enables the server-side response cache; nil = ETag and
+	// conditional requests only.
+	Cache *Cache
+	TTL   time.Duration // server-side lifetime; default DefaultTTL
+	// Tags labels cached responses for InvalidateTags; handlers can add
+	// more with AddTags.
+	Tags func(r *http.Request) []string
+	// Key customises the cache key, e.g. to add the tenant; the default
+	// is path and sorted query. Only GET responses are stored and HEAD
+	// is served from them; Vary headers are appended to the key.
+	// Requests with cookies bypass the cache unless Key is set or the
+	// response varies on Cookie.
+	Key func(r *http.Request) string
+
+	keyed bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.TTL == 0 {
+		c.TTL = DefaultTTL
+	}
+
+	c.keyed = c.Key != nil
+	if c.Key == nil {
+		c.Key = defaultKey
+	}
+
+	return c
+}
+
+type tagsKey struct{}
+
+type tagSet struct {
+	mu   sync.Mutex
+	tags []string
+}
+
+// AddTags labels the response being produced so Cache.InvalidateTags
+// can drop it later. It is a no-op outside Middleware.
+func AddTags(ctx context.Context, tags ...string) {
+	if ts, ok := ctx.Value(tagsKey{}).(*tagSet); ok {
+		ts.mu.Lock()
+		ts.tags = append(ts.tags, tags...)
+		ts.mu.Unlock()
+	}
+}
+
+// Middleware adds strong ETags to successful GET responses, answers
+// If-None-Match / If-Modified-Since with 304 and, with Config.Cache,
+// serves repeated requests from memory. Responses are buffered, so it
+// is not meant for streaming routes.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	cfg = cfg.withDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+			case http.MethodHead:
+				if !serveCached(cfg, w, r) {
+					next.ServeHTTP(w, r)
+				}
+
+				return
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if serveCached(cfg, w, r) {
+				return
+			}
+
+			ts := &tagSet{}
+			if cfg.Tags != nil {
+				ts.tags = slices.Clone(cfg.Tags(r))
+			}
+
+			bw := &bufferingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(bw, r.WithContext(context.WithValue(r.Context(), tagsKey{}, ts)))
+
+			finish(cfg, w, r, bw, ts.tags)
+		})
+	}
+}
+
+func serveCached(cfg Config, w http.ResponseWriter, r *http.Request) bool {
+	if cfg.Cache == nil || noCache(r) {
+		return false
+	}
+
+	base := cfg.Key(r)
+	vary := cfg.Cache.varyHeaders(base)
+
+	if personal(cfg, r, vary) {
+		return false
+	}
+
+	e, ok := cfg.Cache.get(variantKey(base, vary, r))
+	if !ok {
+		return false
+	}
+
+	h := w.Header()
+	for k, v := range e.header {
+		if _, set := h[k]; !set {
+			h[k] = v
+		}
+	}
+
+	h.Set(HeaderCache, "HIT")
+	h.Set("Age", strconv.Itoa(int(cfg.Cache.now().Sub(e.storedAt).Seconds())))
+
+	if notModified(r, h) {
+		writeNotModified(w)
+		return true
+	}
+
+	w.WriteHeader(e.status)
+
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.body)
+	}
+
+	return true
+}
+
+func finish(cfg Config, w http.ResponseWriter, r *http.Request, bw *bufferingWriter, tags []string) {
+	h := w.Header()
+
+	if bw.status == http.StatusOK {
+		if h.Get("ETag") == "" {
+			h.Set("ETag", strongETag(bw.body.Bytes()))
+		}
+
+		if cfg.CacheControl != "" && h.Get("Cache-Control") == "" {
+			h.Set("Cache-Control", cfg.CacheControl)
+		}
+
+		if cfg.Cache != nil && storable(cfg, r, h) {
+			store(cfg, r, bw, tags)
+			h.Set(HeaderCache, "MISS")
+		}
+
+		if notModified(r, h) {
+			writeNotModified(w)
+			return
+		}
+	}
+
+	w.WriteHeader(bw.status)
+	_, _ = w.Write(bw.body.Bytes())
+}
+
+func store(cfg Config, r *http.Request, bw *bufferingWriter, tags []string) {
+	base := cfg.Key(r)
+	header := bw.Header().Clone()
+	vary := varyNames(header)
+	now := cfg.Cache.now()
+
+	cfg.Cache.set(base, vary, &entry{
+		key:       variantKey(base, vary, r),
+		status:    bw.status,
+		header:    header,
+		body:      slices.Clone(bw.body.Bytes()),
+		tags:      slices.Compact(slices.Sorted(slices.Values(tags))),
+		storedAt:  now,
+		expiresAt: now.Add(cfg.TTL),
+	})
+}
+
+func storable(cfg Config, r *http.Request, h http.Header) bool {
+	vary := varyNames(h)
+	if personal(cfg, r, vary) || h.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	if strings.Contains(cc, "no-store") || strings.Contains(cc, "private") {
+		return false
+	}
+
+	return !slices.Contains(vary, "*")
+}
+
+// personal reports requests whose response may depend on credentials
+// the cache key does not capture.
+func personal(cfg Config, r *http.Request, vary []string) bool {
+	if r.Header.Get("Authorization") != "" {
+		return true
+	}
+
+	return r.Header.Get("Cookie") != "" && !cfg.keyed && !slices.Contains(vary, "Cookie")
+}
+
+func noCache(r *http.Request) bool {
+	cc := strings.ToLower(r.Header.Get("Cache-Control"))
+
+	return strings.Contains(cc, "no-cache") || strings.Contains(cc, "no-store")
+}
+
+// notModified evaluates If-None-Match and, only without it,
+// If-Modified-Since (RFC 9110, section 13.2.2).
+func notModified(r *http.Request, h http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, h.Get("ETag"))
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	lm, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !lm.Truncate(time.Second).After(ims)
+}
+
+// etagMatches uses weak comparison, as required for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+		h.Del(k)
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+}
+
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+func defaultKey(r *http.Request) string {
+	q := r.URL.Query()
+	for _, v := range q {
+		slices.Sort(v)
+	}
+
+	// Encode sorts by key.
+	return r.URL.Path + "?" + q.Encode()
+}
+
+func varyNames(h http.Header) []string {
+	var names []string
+
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
+
+func variantKey(base string, vary []string, r *http.Request) string {
+	if len(vary) == 0 {
+		return base
+	}
+
+	var b strings.Builder
+	b.WriteString(base)
+
+	for _, name := range vary {
+		b.WriteString("\n" + name + ":" + url.QueryEscape(strings.Join(r.Header.Values(name), ",")))
+	}
+
+	return b.String()
+}
+
+// bufferingWriter holds the whole response so the ETag can be computed
+// before headers go out; Flush is a no-op so that nothing leaks early.
+type bufferingWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *bufferingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+}
+
+func (w *bufferingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+
+	return w.body.Write(b)
+}
+
+func (w *bufferingWriter) Flush() {}
+
+// Unwrap keeps the router's settings (problem details) visible; the
+// no-op Flush above stops http.ResponseController from flushing past
+// the buffer.
+func (w *bufferingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,237 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func catalog(calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		AddTags(r.Context(), "product:"+r.URL.Query().Get("id"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"` + r.URL.Query().Get("id") + `"}`))
+	})
+}
+
+func get(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func TestMiddleware_ETagAndIfNoneMatch(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	h := Middleware(Config{CacheControl: "public, max-age=60"})(catalog(&calls))
+
+	rr := get(h, "/products?id=1")
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" || etag[0] != '"' {
+		t.Fatalf("expected strong ETag, got %d %q", rr.Code, etag)
+	}
+	if rr.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Fatalf("unexpected Cache-Control %q", rr.Header().Get("Cache-Control"))
+	}
+
+	rr = get(h, "/products?id=1", "If-None-Match", `"other", W/`+etag)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("expected empty 304, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("ETag") != etag {
+		t.Fatal("expected ETag on 304")
+	}
+
+	rr = get(h, "/products?id=2", "If-None-Match", etag)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for different body, got %d", rr.Code)
+	}
+}
+
+func TestMiddleware_HandlerValidators(t *testing.T) {
+	t.Parallel()
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := Middleware(Config{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("ETag", `W/"v7"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		_, _ = w.Write([]byte("body"))
+	}))
+
+	rr := get(h, "/")
+	if rr.Header().Get("ETag") != `W/"v7"` || rr.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("handler validators must win, got %v", rr.Header())
+	}
+
+	rr = get(h, "/", "If-Modified-Since", modified.Add(time.Hour).Format(http.TimeFormat))
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", rr.Code)
+	}
+
+	rr = get(h, "/", "If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for older If-Modified-Since, got %d", rr.Code)
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since.
+	rr = get(h, "/", "If-None-Match", `"v8"`, "If-Modified-Since", modified.Format(http.TimeFormat))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 when ETag differs, got %d", rr.Code)
+	}
+}
+
+func TestMiddleware_ServerCacheAndTags(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	cache := NewCache(10)
+	h := Middleware(Config{
+		Cache: cache,
+		Tags:  func(*http.Request) []string { return []string{"catalog"} },
+	})(catalog(&calls))
+
+	first := get(h, "/products?id=1&b=2")
+	second := get(h, "/products?b=2&id=1")
+	if calls.Load() != 1 {
+		t.Fatalf("expected one handler call, got %d", calls.Load())
+	}
+	if first.Header().Get(HeaderCache) != "MISS" || second.Header().Get(HeaderCache) != "HIT" {
+		t.Fatalf("unexpected cache headers %q %q", first.Header().Get(HeaderCache), second.Header().Get(HeaderCache))
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Fatal("cached response differs")
+	}
+
+	rr := get(h, "/products?id=1&b=2", "If-None-Match", first.Header().Get("ETag"))
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 from cache, got %d", rr.Code)
+	}
+
+	head := httptest.NewRecorder()
+	h.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/products?id=1&b=2", nil))
+	if head.Code != http.StatusOK || head.Body.Len() != 0 || head.Header().Get(HeaderCache) != "HIT" {
+		t.Fatalf("expected HEAD from cache without body, got %d %q", head.Code, head.Body.String())
+	}
+
+	get(h, "/products?id=2")
+	if n := cache.InvalidateTags("product:1"); n != 1 {
+		t.Fatalf("expected one entry invalidated, got %d", n)
+	}
+	get(h, "/products?id=1&b=2")
+	if calls.Load() != 3 {
+		t.Fatalf("expected recompute after invalidation, got %d calls", calls.Load())
+	}
+
+	if n := cache.InvalidateTags("catalog"); n != 2 || cache.Len() != 0 {
+		t.Fatalf("expected catalog tag to drop everything, got %d, len %d", n, cache.Len())
+	}
+}
+
+func TestMiddleware_VaryAndTTL(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	cache := NewCache(10)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	h := Middleware(Config{Cache: cache, TTL: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+
+	get(h, "/", "Accept-Language", "ru")
+	en := get(h, "/", "Accept-Language", "en")
+	ru := get(h, "/", "Accept-Language", "ru")
+	if calls.Load() != 2 || en.Body.String() != "en" || ru.Body.String() != "ru" {
+		t.Fatalf("expected per-language variants, got %d calls, %q %q", calls.Load(), en.Body.String(), ru.Body.String())
+	}
+
+	now = now.Add(2 * time.Minute)
+	get(h, "/", "Accept-Language", "ru")
+	if calls.Load() != 3 {
+		t.Fatalf("expected expired entry to be recomputed, got %d calls", calls.Load())
+	}
+}
+
+func TestMiddleware_NotStored(t *testing.T) {
+	t.Parallel()
+	cache := NewCache(10)
+	cases := map[string]http.HandlerFunc{
+		"private": func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Cache-Control", "private")
+		},
+		"cookie": func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Set-Cookie", "a=b")
+		},
+		"error": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+	}
+	for name, handler := range cases {
+		Middleware(Config{Cache: cache})(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+name, nil))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/post", nil)
+	Middleware(Config{Cache: cache})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if cache.Len() != 0 {
+		t.Fatalf("expected nothing cached, got %d", cache.Len())
+	}
+}
+
+func TestMiddleware_CredentialedRequests(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	cache := NewCache(10)
+	h := Middleware(Config{Cache: cache})(catalog(&calls))
+
+	get(h, "/p?id=1")
+	if rr := get(h, "/p?id=1", "Cookie", "session=alice"); rr.Header().Get(HeaderCache) == "HIT" {
+		t.Fatal("cookie request must not be served from the shared cache")
+	}
+	if rr := get(h, "/p?id=1", "Authorization", "Bearer x"); rr.Header().Get(HeaderCache) == "HIT" {
+		t.Fatal("authorized request must not be served from the shared cache")
+	}
+	get(h, "/p?id=2", "Cookie", "session=alice")
+	if cache.Len() != 1 || calls.Load() != 4 {
+		t.Fatalf("expected only the anonymous response cached, got %d entries, %d calls", cache.Len(), calls.Load())
+	}
+
+	keyed := Middleware(Config{
+		Cache: NewCache(10),
+		Key:   func(r *http.Request) string { return r.URL.String() + "|" + r.Header.Get("Cookie") },
+	})(catalog(&calls))
+	get(keyed, "/p?id=1", "Cookie", "session=alice")
+	if rr := get(keyed, "/p?id=1", "Cookie", "session=alice"); rr.Header().Get(HeaderCache) != "HIT" {
+		t.Fatal("expected an explicit Key to allow caching cookie requests")
+	}
+}
+
+func TestCache_LRUEviction(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	cache := NewCache(2)
+	h := Middleware(Config{Cache: cache})(catalog(&calls))
+
+	get(h, "/p?id=1")
+	get(h, "/p?id=2")
+	get(h, "/p?id=1") // touch 1, 2 becomes least recently used
+	get(h, "/p?id=3")
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+
+	if rr := get(h, "/p?id=1"); rr.Header().Get(HeaderCache) != "HIT" {
+		t.Fatal("expected recently used entry to survive")
+	}
+	if rr := get(h, "/p?id=2"); rr.Header().Get(HeaderCache) != "MISS" {
+		t.Fatal("expected least recently used entry to be evicted")
+	}
+}
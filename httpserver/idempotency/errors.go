@@ -0,0 +1,19 @@
+package idempotency
+
+import domainerrors "github.com/shuldan/errors"
+
+var ErrKeyRequired = domainerrors.NewCode("IDEMPOTENCY_KEY_REQUIRED").
+	Kind(domainerrors.Validation).
+	New("Idempotency-Key header is required")
+
+var ErrKeyTooLong = domainerrors.NewCode("IDEMPOTENCY_KEY_TOO_LONG").
+	Kind(domainerrors.Validation).
+	New("Idempotency-Key header is too long")
+
+var ErrKeyReused = domainerrors.NewCode("IDEMPOTENCY_KEY_REUSED").
+	Kind(domainerrors.DomainRule).
+	New("Idempotency-Key was already used for a different request")
+
+var ErrRequestInProgress = domainerrors.NewCode("IDEMPOTENCY_REQUEST_IN_PROGRESS").
+	Kind(domainerrors.Conflict).
+	New("a request with this Idempotency-Key is still in progress")
@@ -0,0 +1,261 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+type Config struct {
+	Store     Store
+	TTL       time.Duration // default 24h
+	Methods   []string      // default POST, PATCH
+	Required  bool          // reject requests without a key
+	MaxKeyLen int           // default 255
+	// Scope is required: it prefixes the key with the caller's identity
+	// (e.g. user ID) so one client cannot replay another's response.
+	// ScopeByAuthorization covers token auth; return "" for APIs
+	// without users.
+	Scope func(r *http.Request) string
+	// MaxBodySize caps the body read for the fingerprint; larger
+	// requests get ErrBodyTooLarge. Default httpserver.DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+func (c Config) withDefaults() Config {
+	if c.Store == nil {
+		c.Store = NewMemoryStore()
+	}
+
+	if c.TTL == 0 {
+		c.TTL = 24 * time.Hour
+	}
+
+	if len(c.Methods) == 0 {
+		c.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	if c.MaxKeyLen == 0 {
+		c.MaxKeyLen = 255
+	}
+
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = httpserver.DefaultMaxBodySize
+	}
+
+	return c
+}
+
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Scope == nil {
+		panic("idempotency: Config.Scope is required; use ScopeByAuthorization " +
+			"or return the authenticated user ID")
+	}
+
+	cfg = cfg.withDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.appliesTo(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(HeaderKey)
+			if err := cfg.validateKey(key); err != nil {
+				httpserver.Error(w, err)
+				return
+			}
+
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			serveIdempotent(cfg, w, r, next, cfg.scopedKey(r, key))
+		})
+	}
+}
+
+func serveIdempotent(
+	cfg Config, w http.ResponseWriter, r *http.Request,
+	next http.Handler, key string,
+) {
+	fp, err := fingerprint(r, cfg.MaxBodySize)
+	if err != nil {
+		httpserver.Error(w, err)
+		return
+	}
+
+	existing, reserved, err := cfg.Store.Reserve(r.Context(), key, fp, cfg.TTL)
+	if err != nil {
+		httpserver.Error(w, err)
+		return
+	}
+
+	if !reserved {
+		replay(w, existing, fp)
+		return
+	}
+
+	rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+	completed := false
+
+	// Clients that retry have often given up on this request already;
+	// the outcome must be stored even though r.Context() is canceled.
+	ctx := context.WithoutCancel(r.Context())
+
+	defer func() {
+		if !completed {
+			_ = cfg.Store.Release(ctx, key)
+		}
+	}()
+
+	next.ServeHTTP(rw, r)
+
+	if rw.status >= http.StatusInternalServerError {
+		return
+	}
+
+	completed = cfg.Store.Complete(ctx, Record{
+		Key:         key,
+		Fingerprint: fp,
+		Status:      rw.status,
+		Header:      rw.recordedHeader(),
+		Body:        rw.body.Bytes(),
+	}) == nil
+}
+
+func replay(w http.ResponseWriter, rec *Record, fp string) {
+	switch {
+	case rec.Fingerprint != fp:
+		httpserver.Error(w, ErrKeyReused)
+	case !rec.Completed:
+		httpserver.Error(w, ErrRequestInProgress)
+	default:
+		// Headers set by outer middleware for this request (request ID,
+		// CORS) win over the recorded ones.
+		for k, v := range rec.Header {
+			if _, ok := w.Header()[k]; !ok {
+				w.Header()[k] = v
+			}
+		}
+
+		w.Header().Set(HeaderReplayed, "true")
+		w.WriteHeader(rec.Status)
+		_, _ = w.Write(rec.Body)
+	}
+}
+
+func (c Config) appliesTo(method string) bool {
+	for _, m := range c.Methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Config) validateKey(key string) error {
+	if key == "" && c.Required {
+		return ErrKeyRequired
+	}
+
+	if len(key) > c.MaxKeyLen {
+		return ErrKeyTooLong
+	}
+
+	return nil
+}
+
+func (c Config) scopedKey(r *http.Request, key string) string {
+	return c.Scope(r) + ":" + key
+}
+
+// ScopeByAuthorization scopes keys to the Authorization header, so
+// each token gets its own key space without storing the token itself.
+func ScopeByAuthorization(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(auth))
+
+	return fmt.Sprintf("auth-%x", sum[:16])
+}
+
+func fingerprint(r *http.Request, maxBody int64) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			return "", err
+		}
+
+		if int64(len(body)) > maxBody {
+			return "", httpserver.ErrBodyTooLarge
+		}
+
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.header = w.ResponseWriter.Header().Clone()
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) recordedHeader() http.Header {
+	if w.header == nil {
+		return w.ResponseWriter.Header().Clone()
+	}
+
+	return w.header
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
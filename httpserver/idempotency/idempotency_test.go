@@ -0,0 +1,258 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(countingHandler(&calls, http.StatusCreated))
+	first := post(handler, "key-1", `{"amount":10}`)
+	second := post(handler, "key-1", `{"amount":10}`)
+	if calls.Load() != 1 {
+		t.Fatalf("expected handler called once, got %d", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replayed response, got %d %q", second.Code, second.Body.String())
+	}
+	if second.Header().Get(HeaderReplayed) != "true" {
+		t.Error("expected replay header")
+	}
+	if second.Header().Get("X-Order") != "1" {
+		t.Error("expected recorded headers to be replayed")
+	}
+}
+
+func TestMiddleware_DifferentFingerprint(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(countingHandler(&calls, http.StatusCreated))
+	post(handler, "key-1", `{"amount":10}`)
+	rr := post(handler, "key-1", `{"amount":99}`)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rr.Code)
+	}
+}
+
+func TestMiddleware_InFlightDuplicate(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+	done := make(chan struct{})
+	go func() {
+		post(handler, "key-1", "{}")
+		close(done)
+	}()
+	<-started
+	rr := post(handler, "key-1", "{}")
+	close(release)
+	<-done
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rr.Code)
+	}
+}
+
+func TestMiddleware_ServerErrorReleasesKey(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(countingHandler(&calls, http.StatusInternalServerError))
+	post(handler, "key-1", "{}")
+	post(handler, "key-1", "{}")
+	if calls.Load() != 2 {
+		t.Fatalf("expected retry after 5xx, got %d calls", calls.Load())
+	}
+}
+
+func TestMiddleware_PanicReleasesKey(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryStore()
+	handler := Middleware(Config{Store: store, Scope: ScopeByAuthorization})(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			panic("boom")
+		}),
+	)
+	func() {
+		defer func() { _ = recover() }()
+		post(handler, "key-1", "{}")
+	}()
+	_, reserved, _ := store.Reserve(context.Background(), "key-1", "fp", time.Minute)
+	if !reserved {
+		t.Fatal("expected key to be released after panic")
+	}
+}
+
+func TestMiddleware_RequiredKey(t *testing.T) {
+	t.Parallel()
+	handler := Middleware(Config{Required: true, Scope: ScopeByAuthorization})(okHandler())
+	rr := post(handler, "", "{}")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestMiddleware_KeyTooLong(t *testing.T) {
+	t.Parallel()
+	handler := Middleware(Config{MaxKeyLen: 4, Scope: ScopeByAuthorization})(okHandler())
+	rr := post(handler, "too-long", "{}")
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestMiddleware_SkipsOtherMethodsAndMissingKey(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(countingHandler(&calls, http.StatusOK))
+	for range 2 {
+		req := httptest.NewRequest("GET", "/orders", nil)
+		req.Header.Set(HeaderKey, "key-1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		post(handler, "", "{}")
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("expected 4 calls, got %d", calls.Load())
+	}
+}
+
+func TestMiddleware_ScopeSeparatesKeys(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{
+		Scope: func(r *http.Request) string { return r.Header.Get("X-User") },
+	})(countingHandler(&calls, http.StatusCreated))
+	for _, user := range []string{"alice", "bob"} {
+		req := httptest.NewRequest("POST", "/orders", strings.NewReader("{}"))
+		req.Header.Set(HeaderKey, "key-1")
+		req.Header.Set("X-User", user)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls.Load())
+	}
+}
+
+func TestMiddleware_ScopeByAuthorization(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(countingHandler(&calls, http.StatusCreated))
+	for _, token := range []string{"Bearer alice", "Bearer bob", "Bearer alice"} {
+		req := httptest.NewRequest("POST", "/orders", strings.NewReader("{}"))
+		req.Header.Set(HeaderKey, "key-1")
+		req.Header.Set("Authorization", token)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected one call per token, got %d", calls.Load())
+	}
+}
+
+func TestMiddleware_RequiresScope(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic without Scope")
+		}
+	}()
+	Middleware(Config{})
+}
+
+func TestMiddleware_HandlerSeesBody(t *testing.T) {
+	t.Parallel()
+	var body string
+	handler := Middleware(Config{Scope: ScopeByAuthorization})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+		}),
+	)
+	post(handler, "key-1", `{"a":1}`)
+	if body != `{"a":1}` {
+		t.Fatalf("expected body to be restored, got %q", body)
+	}
+}
+
+func countingHandler(calls *atomic.Int32, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("X-Order", "1")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte{byte('0' + n)})
+	})
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func post(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+// ctxStore fails like a database driver once the context is canceled.
+type ctxStore struct{ *MemoryStore }
+
+func (s ctxStore) Complete(ctx context.Context, rec Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Complete(ctx, rec)
+}
+
+func TestMiddleware_CompletesAfterClientDisconnect(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := countingHandler(&calls, http.StatusCreated)
+	handler := Middleware(Config{Store: ctxStore{NewMemoryStore()}, Scope: ScopeByAuthorization})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inner.ServeHTTP(w, r)
+			cancel() // client timed out while the handler was finishing
+		}),
+	)
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader("{}")).WithContext(ctx)
+	req.Header.Set(HeaderKey, "key-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	retry := post(handler, "key-1", "{}")
+	if calls.Load() != 1 || retry.Header().Get(HeaderReplayed) != "true" {
+		t.Fatalf("expected replay after disconnect, got %d calls, status %d", calls.Load(), retry.Code)
+	}
+}
+
+func TestMiddleware_BodyTooLarge(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	handler := Middleware(Config{MaxBodySize: 8, Scope: ScopeByAuthorization})(countingHandler(&calls, http.StatusCreated))
+	if rr := post(handler, "key-1", `{"amount":10}`); rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rr.Code)
+	}
+	if rr := post(handler, "key-2", `{"a":1}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201 within limit, got %d", rr.Code)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected 1 call, got %d", calls.Load())
+	}
+}
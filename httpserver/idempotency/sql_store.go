@@ -0,0 +1,216 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shuldan/migrator"
+
+	"github.com/shuldan/framework/database"
+	"github.com/shuldan/framework/migration"
+)
+
+const defaultTable = "idempotency_keys"
+
+type SQLStoreOption func(*SQLStore)
+
+func WithTableName(name string) SQLStoreOption {
+	return func(s *SQLStore) {
+		s.table = name
+	}
+}
+
+type SQLStore struct {
+	db      *sql.DB
+	dialect migrator.Dialect
+	table   string
+	now     func() time.Time
+}
+
+func NewSQLStore(
+	dbm *database.Manager, connection string, opts ...SQLStoreOption,
+) *SQLStore {
+	s := &SQLStore{
+		db:      dbm.Connection(connection),
+		dialect: migration.DriverDialect(dbm.Driver(connection)),
+		table:   defaultTable,
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Migration creates the table used by SQLStore; register it with
+// migration.Runner for the same connection.
+func Migration(table string) migrator.Migration {
+	if table == "" {
+		table = defaultTable
+	}
+
+	return migrator.CreateMigration(
+		"idempotency_create_"+table,
+		"Create idempotency keys table",
+	).CreateTable(table,
+		"idempotency_key VARCHAR(512) PRIMARY KEY",
+		"fingerprint VARCHAR(64) NOT NULL",
+		"completed INTEGER NOT NULL DEFAULT 0",
+		"status INTEGER NOT NULL DEFAULT 0",
+		"headers TEXT",
+		"body TEXT",
+		"expires_at BIGINT NOT NULL",
+	).CreateIndex(
+		"idx_"+table+"_expires_at", table, "expires_at",
+	).MustBuild()
+}
+
+func (s *SQLStore) Reserve(
+	ctx context.Context, key, fingerprint string, ttl time.Duration,
+) (*Record, bool, error) {
+	now := s.now()
+
+	_, err := s.db.ExecContext(ctx, s.query(
+		"DELETE FROM %s WHERE idempotency_key = ? AND expires_at <= ?",
+	), key, now.UnixNano())
+	if err != nil {
+		return nil, false, fmt.Errorf("idempotency: purge key: %w", err)
+	}
+
+	_, insertErr := s.db.ExecContext(ctx, s.query(
+		"INSERT INTO %s (idempotency_key, fingerprint, completed, status, expires_at) "+
+			"VALUES (?, ?, 0, 0, ?)",
+	), key, fingerprint, now.Add(ttl).UnixNano())
+	if insertErr == nil {
+		return nil, true, nil
+	}
+
+	rec, err := s.load(ctx, key)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"idempotency: reserve key: %w", errors.Join(insertErr, err),
+		)
+	}
+
+	return rec, false, nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, rec Record) error {
+	headers, err := json.Marshal(rec.Header)
+	if err != nil {
+		return fmt.Errorf("idempotency: encode headers: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, s.query(
+		"UPDATE %s SET completed = 1, status = ?, headers = ?, body = ? "+
+			"WHERE idempotency_key = ?",
+	), rec.Status, string(headers),
+		base64.StdEncoding.EncodeToString(rec.Body), rec.Key)
+	if err != nil {
+		return fmt.Errorf("idempotency: complete key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.query(
+		"DELETE FROM %s WHERE idempotency_key = ?",
+	), key)
+	if err != nil {
+		return fmt.Errorf("idempotency: release key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLStore) Purge(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.query(
+		"DELETE FROM %s WHERE expires_at <= ?",
+	), s.now().UnixNano())
+	if err != nil {
+		return fmt.Errorf("idempotency: purge: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLStore) load(ctx context.Context, key string) (*Record, error) {
+	var (
+		rec       Record
+		completed int
+		headers   sql.NullString
+		body      sql.NullString
+		expiresAt int64
+	)
+
+	err := s.db.QueryRowContext(ctx, s.query(
+		"SELECT fingerprint, completed, status, headers, body, expires_at "+
+			"FROM %s WHERE idempotency_key = ?",
+	), key).Scan(
+		&rec.Fingerprint, &completed, &rec.Status,
+		&headers, &body, &expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.Key = key
+	rec.Completed = completed == 1
+	rec.ExpiresAt = time.Unix(0, expiresAt)
+
+	if err := decodeStored(&rec, headers.String, body.String); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+func decodeStored(rec *Record, headers, body string) error {
+	if headers != "" {
+		rec.Header = make(http.Header)
+		if err := json.Unmarshal([]byte(headers), &rec.Header); err != nil {
+			return err
+		}
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return err
+	}
+
+	rec.Body = decoded
+
+	return nil
+}
+
+func (s *SQLStore) query(format string) string {
+	q := fmt.Sprintf(format, s.dialect.QuoteIdentifier(s.table))
+	if s.dialect != migrator.DialectPostgreSQL {
+		return q
+	}
+
+	var b strings.Builder
+
+	n := 0
+	for _, ch := range q {
+		if ch == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+
+		b.WriteRune(ch)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,202 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shuldan/migrator"
+
+	"github.com/shuldan/framework/database"
+)
+
+func init() {
+	sql.Register("idemtestdb", &idemDriver{})
+}
+
+func TestSQLStore_ReserveCompleteReplay(t *testing.T) {
+	t.Parallel()
+	s := newTestSQLStore(t, "reserve")
+	ctx := context.Background()
+	_, reserved, err := s.Reserve(ctx, "k", "fp", time.Minute)
+	if err != nil || !reserved {
+		t.Fatalf("expected reserve, got %v %v", reserved, err)
+	}
+	existing, reserved, err := s.Reserve(ctx, "k", "fp", time.Minute)
+	if err != nil || reserved || existing.Completed {
+		t.Fatalf("expected pending record, got %+v %v %v", existing, reserved, err)
+	}
+	err = s.Complete(ctx, Record{
+		Key:    "k",
+		Status: http.StatusCreated,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   []byte(`{"id":1}`),
+	})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	existing, _, _ = s.Reserve(ctx, "k", "fp", time.Minute)
+	if !existing.Completed || existing.Status != 201 || string(existing.Body) != `{"id":1}` {
+		t.Fatalf("unexpected record: %+v", existing)
+	}
+	if existing.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected headers to round-trip, got %v", existing.Header)
+	}
+}
+
+func TestSQLStore_ReleaseAndExpiry(t *testing.T) {
+	t.Parallel()
+	s := newTestSQLStore(t, "release")
+	ctx := context.Background()
+	_, _, _ = s.Reserve(ctx, "k", "fp", time.Minute)
+	if err := s.Release(ctx, "k"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, reserved, _ := s.Reserve(ctx, "k", "fp", time.Minute); !reserved {
+		t.Fatal("expected key reusable after release")
+	}
+	now := time.Now().Add(time.Hour)
+	s.now = func() time.Time { return now }
+	if _, reserved, _ := s.Reserve(ctx, "k", "fp", time.Minute); !reserved {
+		t.Fatal("expected expired key reusable")
+	}
+	now = now.Add(time.Hour)
+	if err := s.Purge(ctx); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+}
+
+func TestSQLStore_PostgresPlaceholders(t *testing.T) {
+	t.Parallel()
+	s := &SQLStore{table: "keys", dialect: migrator.DialectPostgreSQL}
+	q := s.query("DELETE FROM %s WHERE a = ? AND b = ?")
+	if q != `DELETE FROM "keys" WHERE a = $1 AND b = $2` {
+		t.Fatalf("unexpected query: %s", q)
+	}
+}
+
+func TestMigration_CreatesTable(t *testing.T) {
+	t.Parallel()
+	m := Migration("")
+	if len(m.Up()) == 0 || !strings.Contains(m.Up()[0], defaultTable) {
+		t.Fatalf("unexpected up queries: %v", m.Up())
+	}
+	if len(m.Down()) == 0 {
+		t.Fatal("expected reversible migration")
+	}
+}
+
+func newTestSQLStore(t *testing.T, dsn string) *SQLStore {
+	t.Helper()
+	dbm, err := database.NewManager(map[string]database.ConnectionConfig{
+		"default": {Driver: "idemtestdb", DSN: dsn},
+	}, nil)
+	if err != nil {
+		t.Fatalf("manager: %v", err)
+	}
+	t.Cleanup(func() { _ = dbm.Stop(context.Background()) })
+	return NewSQLStore(dbm, "default", WithTableName("idem"))
+}
+
+// idemDriver understands exactly the statements SQLStore issues and
+// keeps rows per DSN in memory.
+type idemDriver struct {
+	mu    sync.Mutex
+	state map[string]map[string][]driver.Value
+}
+
+func (d *idemDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.state == nil {
+		d.state = make(map[string]map[string][]driver.Value)
+	}
+	if d.state[dsn] == nil {
+		d.state[dsn] = make(map[string][]driver.Value)
+	}
+	return &idemConn{d: d, rows: d.state[dsn]}, nil
+}
+
+type idemConn struct {
+	d    *idemDriver
+	rows map[string][]driver.Value
+}
+
+func (c *idemConn) Prepare(q string) (driver.Stmt, error) { return &idemStmt{c: c, q: q}, nil }
+func (c *idemConn) Close() error                          { return nil }
+func (c *idemConn) Begin() (driver.Tx, error)             { return nil, errors.New("no tx") }
+
+type idemStmt struct {
+	c *idemConn
+	q string
+}
+
+func (s *idemStmt) Close() error  { return nil }
+func (s *idemStmt) NumInput() int { return -1 }
+
+func (s *idemStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	rows := s.c.rows
+	switch {
+	case strings.HasPrefix(s.q, "INSERT"):
+		key := args[0].(string)
+		if _, ok := rows[key]; ok {
+			return nil, errors.New("unique violation")
+		}
+		rows[key] = []driver.Value{args[1], int64(0), int64(0), nil, nil, args[2]}
+	case strings.HasPrefix(s.q, "UPDATE"):
+		row := rows[args[3].(string)]
+		row[1], row[2], row[3], row[4] = int64(1), args[0], args[1], args[2]
+	case strings.Contains(s.q, "idempotency_key = ? AND expires_at"):
+		if row, ok := rows[args[0].(string)]; ok && row[5].(int64) <= args[1].(int64) {
+			delete(rows, args[0].(string))
+		}
+	case strings.Contains(s.q, "idempotency_key = ?"):
+		delete(rows, args[0].(string))
+	default:
+		for k, row := range rows {
+			if row[5].(int64) <= args[0].(int64) {
+				delete(rows, k)
+			}
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *idemStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	row, ok := s.c.rows[args[0].(string)]
+	if !ok {
+		return &idemRows{}, nil
+	}
+	return &idemRows{row: append([]driver.Value(nil), row...)}, nil
+}
+
+type idemRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *idemRows) Columns() []string {
+	return []string{"fingerprint", "completed", "status", "headers", "body", "expires_at"}
+}
+
+func (r *idemRows) Close() error { return nil }
+
+func (r *idemRows) Next(dest []driver.Value) error {
+	if r.done || r.row == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
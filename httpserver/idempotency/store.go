@@ -0,0 +1,111 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Record struct {
+	Key         string
+	Fingerprint string
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Store persists idempotency records. Reserve must be atomic: exactly
+// one caller gets reserved == true for a live key, everyone else gets
+// the existing record.
+type Store interface {
+	Reserve(
+		ctx context.Context, key, fingerprint string, ttl time.Duration,
+	) (existing *Record, reserved bool, err error)
+	Complete(ctx context.Context, rec Record) error
+	Release(ctx context.Context, key string) error
+}
+
+// purgeInterval bounds how often Reserve sweeps expired records.
+const purgeInterval = time.Minute
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]Record
+	now       func() time.Time
+	nextPurge time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]Record),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Reserve(
+	_ context.Context, key, fingerprint string, ttl time.Duration,
+) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !now.Before(s.nextPurge) {
+		s.purge(now)
+		s.nextPurge = now.Add(purgeInterval)
+	}
+
+	if rec, ok := s.records[key]; ok && now.Before(rec.ExpiresAt) {
+		return &rec, false, nil
+	}
+
+	s.records[key] = Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prev, ok := s.records[rec.Key]; ok && rec.ExpiresAt.IsZero() {
+		rec.ExpiresAt = prev.ExpiresAt
+	}
+
+	rec.Completed = true
+	s.records[rec.Key] = rec
+
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+// Purge drops expired records. Reserve already does it at most once
+// per minute, so calling it is only needed to free memory eagerly.
+func (s *MemoryStore) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge(s.now())
+}
+
+func (s *MemoryStore) purge(now time.Time) {
+	for key, rec := range s.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_ReserveOnce(t *testing.T) {
+	t.Parallel()
+	s := NewMemoryStore()
+	ctx := context.Background()
+	_, reserved, _ := s.Reserve(ctx, "k", "fp", time.Minute)
+	if !reserved {
+		t.Fatal("expected first reserve to succeed")
+	}
+	existing, reserved, _ := s.Reserve(ctx, "k", "fp", time.Minute)
+	if reserved || existing == nil || existing.Completed {
+		t.Fatalf("expected pending record, got %+v reserved=%v", existing, reserved)
+	}
+}
+
+func TestMemoryStore_CompleteKeepsExpiry(t *testing.T) {
+	t.Parallel()
+	s := NewMemoryStore()
+	ctx := context.Background()
+	_, _, _ = s.Reserve(ctx, "k", "fp", time.Minute)
+	_ = s.Complete(ctx, Record{Key: "k", Fingerprint: "fp", Status: 201})
+	existing, _, _ := s.Reserve(ctx, "k", "fp", time.Minute)
+	if existing == nil || !existing.Completed || existing.Status != 201 {
+		t.Fatalf("unexpected record: %+v", existing)
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	t.Parallel()
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	_, _, _ = s.Reserve(ctx, "k", "fp", time.Minute)
+	now = now.Add(2 * time.Minute)
+	_, reserved, _ := s.Reserve(ctx, "k", "fp2", time.Minute)
+	if !reserved {
+		t.Fatal("expected expired key to be reusable")
+	}
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	t.Parallel()
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	_, _, _ = s.Reserve(context.Background(), "k", "fp", time.Minute)
+	now = now.Add(time.Hour)
+	s.Purge()
+	if len(s.records) != 0 {
+		t.Fatalf("expected purge to remove expired records, got %d", len(s.records))
+	}
+}
+
+func TestMemoryStore_ReserveEvictsExpired(t *testing.T) {
+	t.Parallel()
+	s := NewMemoryStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	_, _, _ = s.Reserve(ctx, "old", "fp", time.Minute)
+	now = now.Add(time.Hour)
+	_, _, _ = s.Reserve(ctx, "new", "fp", time.Minute)
+	if _, ok := s.records["old"]; ok || len(s.records) != 1 {
+		t.Fatalf("expected expired record evicted, got %d records", len(s.records))
+	}
+}
@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/url"
+)
+
+// ClientIdentity describes the verified mTLS client certificate.
+type ClientIdentity struct {
+	Subject        pkix.Name
+	CommonName     string
+	DNSNames       []string
+	EmailAddresses []string
+	URIs           []*url.URL // e.g. SPIFFE IDs
+	SerialNumber   string
+	Certificate    *x509.Certificate
+}
+
+type clientIdentityKey struct{}
+
+// ClientIdentityFromContext returns the identity of a client that
+// presented a certificate verified against TLSConfig.ClientCAFile.
+func ClientIdentityFromContext(ctx context.Context) (*ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityKey{}).(*ClientIdentity)
+	return id, ok
+}
+
+func withClientIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cert := r.TLS.VerifiedChains[0][0]
+		id := &ClientIdentity{
+			Subject:        cert.Subject,
+			CommonName:     cert.Subject.CommonName,
+			DNSNames:       cert.DNSNames,
+			EmailAddresses: cert.EmailAddresses,
+			URIs:           cert.URIs,
+			SerialNumber:   cert.SerialNumber.String(),
+			Certificate:    cert,
+		}
+
+		ctx := context.WithValue(r.Context(), clientIdentityKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
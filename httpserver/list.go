@@ -0,0 +1,303 @@
+package httpserver
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultListLimit     = 20
+	DefaultMaxListLimit  = 100
+	DefaultMaxSortFields = 3
+	DefaultMaxFilterIn   = 50
+)
+
+// Filter operators accepted as ?field[op]=value; a bare ?field=value
+// is OpEq.
+const (
+	OpEq  = "eq"
+	OpNe  = "ne"
+	OpLt  = "lt"
+	OpLte = "lte"
+	OpGt  = "gt"
+	OpGte = "gte"
+	OpIn  = "in"
+)
+
+// ListConfig is the per-endpoint contract for ParseList.
+type ListConfig struct {
+	DefaultLimit int // 0 = DefaultListLimit
+	MaxLimit     int // 0 = DefaultMaxListLimit
+	// Sort lists the fields clients may sort by; DefaultSort applies
+	// when ?sort is absent, e.g. "-created_at,id".
+	Sort          []string
+	DefaultSort   string
+	MaxSortFields int // 0 = DefaultMaxSortFields
+	// Filters maps filterable fields to their allowed operators; an
+	// empty list allows OpEq only.
+	Filters     map[string][]string
+	MaxFilterIn int // values per OpIn filter; 0 = DefaultMaxFilterIn
+	// CursorSecret enables ?cursor=; tokens are HMAC-signed with it.
+	CursorSecret []byte
+}
+
+func (c ListConfig) withDefaults() ListConfig {
+	if c.DefaultLimit == 0 {
+		c.DefaultLimit = DefaultListLimit
+	}
+
+	if c.MaxLimit == 0 {
+		c.MaxLimit = DefaultMaxListLimit
+	}
+
+	if c.MaxSortFields == 0 {
+		c.MaxSortFields = DefaultMaxSortFields
+	}
+
+	if c.MaxFilterIn == 0 {
+		c.MaxFilterIn = DefaultMaxFilterIn
+	}
+
+	return c
+}
+
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+type Filter struct {
+	Field  string   `json:"field"`
+	Op     string   `json:"op"`
+	Values []string `json:"values"` // one value unless Op is OpIn
+}
+
+// ListQuery is the parsed ?page=&limit=&offset=&cursor=&sort= and
+// filter parameters of a list request.
+type ListQuery struct {
+	Limit   int
+	Page    int // 1-based; 0 in cursor mode
+	Offset  int
+	Sort    []SortField
+	Filters []Filter
+
+	cursor *cursorToken
+	cfg    ListConfig
+}
+
+// ParseList reads pagination, sort and filter parameters, enforcing
+// the limits and allow-lists of cfg. Violations are reported together
+// as ErrValidation with a "fields" detail; a tampered or foreign
+// cursor is ErrInvalidCursor.
+func ParseList(r *http.Request, cfg ListConfig) (ListQuery, error) {
+	cfg = cfg.withDefaults()
+	query := r.URL.Query()
+	q := ListQuery{Limit: cfg.DefaultLimit, Page: 1, cfg: cfg}
+
+	var errs []FieldError
+
+	q.parseLimit(query, &errs)
+	q.parseSort(query, &errs)
+	q.parseFilters(query, &errs)
+
+	if token := query.Get("cursor"); token != "" && len(errs) == 0 {
+		cur, err := q.decodeCursor(token)
+		if err != nil {
+			return q, err
+		}
+
+		q.cursor, q.Page, q.Offset = cur, 0, 0
+	} else {
+		q.parseOffset(query, &errs)
+	}
+
+	if len(errs) > 0 {
+		return q, ErrValidation.WithDetail("fields", errs)
+	}
+
+	return q, nil
+}
+
+// SortString renders Sort back to the ?sort= form.
+func (q ListQuery) SortString() string {
+	parts := make([]string, len(q.Sort))
+
+	for i, s := range q.Sort {
+		parts[i] = s.Field
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// FilterValues returns the values of the field/op filter, if present.
+func (q ListQuery) FilterValues(field, op string) ([]string, bool) {
+	for _, f := range q.Filters {
+		if f.Field == field && f.Op == op {
+			return f.Values, true
+		}
+	}
+
+	return nil, false
+}
+
+func (q *ListQuery) parseLimit(query map[string][]string, errs *[]FieldError) {
+	raw := queryFirst(query, "limit")
+	if raw == "" {
+		return
+	}
+
+	n, err := strconv.Atoi(raw)
+
+	switch {
+	case err != nil:
+		*errs = append(*errs, queryError("limit", "type", "expected integer"))
+	case n < 1 || n > q.cfg.MaxLimit:
+		*errs = append(*errs, queryError("limit", "max",
+			"must be between 1 and "+strconv.Itoa(q.cfg.MaxLimit)))
+	default:
+		q.Limit = n
+	}
+}
+
+func (q *ListQuery) parseOffset(query map[string][]string, errs *[]FieldError) {
+	if raw := queryFirst(query, "offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			*errs = append(*errs, queryError("offset", "min", "must be a non-negative integer"))
+			return
+		}
+
+		if n > q.maxOffset() {
+			*errs = append(*errs, queryError("offset", "max", "is too large"))
+			return
+		}
+
+		q.Offset = n
+		q.Page = n/q.Limit + 1
+
+		return
+	}
+
+	if raw := queryFirst(query, "page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			*errs = append(*errs, queryError("page", "min", "must be a positive integer"))
+			return
+		}
+
+		if n-1 > q.maxOffset()/q.Limit {
+			*errs = append(*errs, queryError("page", "max", "is too large"))
+			return
+		}
+
+		q.Page = n
+		q.Offset = (n - 1) * q.Limit
+	}
+}
+
+// maxOffset keeps Offset+Limit (the next page link) from overflowing.
+func (q *ListQuery) maxOffset() int {
+	return math.MaxInt - q.Limit
+}
+
+func (q *ListQuery) parseSort(query map[string][]string, errs *[]FieldError) {
+	raw := queryFirst(query, "sort")
+	if raw == "" {
+		raw = q.cfg.DefaultSort
+	}
+
+	if raw == "" {
+		return
+	}
+
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field, desc := strings.CutPrefix(part, "-")
+		field = strings.TrimPrefix(field, "+")
+
+		if !slices.Contains(q.cfg.Sort, field) {
+			*errs = append(*errs, queryError("sort", "oneof",
+				"cannot sort by "+strconv.Quote(field)+"; allowed: "+strings.Join(q.cfg.Sort, " ")))
+
+			return
+		}
+
+		if seen[field] {
+			continue
+		}
+
+		seen[field] = true
+		q.Sort = append(q.Sort, SortField{Field: field, Desc: desc})
+	}
+
+	if len(q.Sort) > q.cfg.MaxSortFields {
+		*errs = append(*errs, queryError("sort", "max",
+			"must be at most "+strconv.Itoa(q.cfg.MaxSortFields)+" fields"))
+	}
+}
+
+func (q *ListQuery) parseFilters(query map[string][]string, errs *[]FieldError) {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	// Deterministic order for handlers and error messages.
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		field, op := key, OpEq
+		if name, rest, ok := strings.Cut(key, "["); ok && strings.HasSuffix(rest, "]") {
+			field, op = name, strings.TrimSuffix(rest, "]")
+		}
+
+		allowed, ok := q.cfg.Filters[field]
+		if !ok {
+			continue
+		}
+
+		if len(allowed) == 0 {
+			allowed = []string{OpEq}
+		}
+
+		if !slices.Contains(allowed, op) {
+			*errs = append(*errs, queryError(key, "oneof",
+				"operator "+strconv.Quote(op)+" not allowed; allowed: "+strings.Join(allowed, " ")))
+
+			continue
+		}
+
+		values := query[key][:1]
+		if op == OpIn {
+			values = strings.Split(query[key][0], ",")
+			if len(values) > q.cfg.MaxFilterIn {
+				*errs = append(*errs, queryError(key, "max",
+					"must be at most "+strconv.Itoa(q.cfg.MaxFilterIn)+" items"))
+
+				continue
+			}
+		}
+
+		q.Filters = append(q.Filters, Filter{Field: field, Op: op, Values: values})
+	}
+}
+
+func queryFirst(query map[string][]string, key string) string {
+	if v := query[key]; len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+
+	return ""
+}
+
+func queryError(field, rule, msg string) FieldError {
+	return FieldError{Field: field, Source: "query", Rule: rule, Message: msg}
+}
@@ -0,0 +1,226 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+var listCfg = ListConfig{
+	MaxLimit:     50,
+	Sort:         []string{"name", "created_at", "id"},
+	DefaultSort:  "-created_at",
+	Filters:      map[string][]string{"status": {OpEq, OpIn}, "age": {OpGte, OpLt}, "team": nil},
+	MaxFilterIn:  3,
+	CursorSecret: []byte("secret"),
+}
+
+func TestParseList_Defaults(t *testing.T) {
+	t.Parallel()
+	q, err := ParseList(httptest.NewRequest("GET", "/users", nil), listCfg)
+	assertNoErr(t, err)
+	if q.Limit != DefaultListLimit || q.Page != 1 || q.Offset != 0 {
+		t.Fatalf("unexpected paging: %+v", q)
+	}
+	if q.SortString() != "-created_at" {
+		t.Fatalf("expected default sort, got %q", q.SortString())
+	}
+}
+
+func TestParseList_PageAndOffset(t *testing.T) {
+	t.Parallel()
+	q, err := ParseList(httptest.NewRequest("GET", "/users?page=3&limit=10", nil), listCfg)
+	assertNoErr(t, err)
+	if q.Page != 3 || q.Offset != 20 {
+		t.Fatalf("unexpected paging: %+v", q)
+	}
+	q, err = ParseList(httptest.NewRequest("GET", "/users?offset=25&limit=10", nil), listCfg)
+	assertNoErr(t, err)
+	if q.Page != 3 || q.Offset != 25 {
+		t.Fatalf("unexpected paging: %+v", q)
+	}
+}
+
+func TestParseList_SortAndFilters(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("GET", "/users?sort=name,-id,name&status[in]=a,b&age[gte]=18&team=core", nil)
+	q, err := ParseList(r, listCfg)
+	assertNoErr(t, err)
+	want := []SortField{{Field: "name"}, {Field: "id", Desc: true}}
+	if !slices.Equal(q.Sort, want) {
+		t.Fatalf("unexpected sort: %+v", q.Sort)
+	}
+	if v, ok := q.FilterValues("status", OpIn); !ok || !slices.Equal(v, []string{"a", "b"}) {
+		t.Fatalf("unexpected status filter: %v", v)
+	}
+	if v, ok := q.FilterValues("age", OpGte); !ok || v[0] != "18" {
+		t.Fatalf("unexpected age filter: %v", v)
+	}
+	if _, ok := q.FilterValues("team", OpEq); !ok {
+		t.Fatal("expected team filter")
+	}
+}
+
+func TestParseList_Violations(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		query, field string
+	}{
+		{"limit=500", "limit"},
+		{"limit=x", "limit"},
+		{"page=0", "page"},
+		{"offset=-1", "offset"},
+		{"page=" + strconv.Itoa(math.MaxInt/20+2), "page"},
+		{"offset=" + strconv.Itoa(math.MaxInt), "offset"},
+		{"sort=password", "sort"},
+		{"sort=name,created_at,id,-name&limit=1", ""},
+		{"status[ne]=a", "status[ne]"},
+		{"team[gt]=a", "team[gt]"},
+		{"status[in]=a,b,c,d", "status[in]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseList(httptest.NewRequest("GET", "/users?"+tt.query, nil), listCfg)
+			if tt.field == "" {
+				assertNoErr(t, err)
+				return
+			}
+			var de *domainerrors.Error
+			if !errors.As(err, &de) || !errors.Is(err, ErrValidation) {
+				t.Fatalf("expected ErrValidation, got %v", err)
+			}
+			fields, _ := de.Details()["fields"].([]FieldError)
+			if len(fields) != 1 || fields[0].Field != tt.field || fields[0].Source != "query" {
+				t.Fatalf("unexpected fields: %+v", fields)
+			}
+		})
+	}
+}
+
+func TestParseList_MaxSortFields(t *testing.T) {
+	t.Parallel()
+	cfg := listCfg
+	cfg.MaxSortFields = 2
+	_, err := ParseList(httptest.NewRequest("GET", "/users?sort=name,id,created_at", nil), cfg)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("expected ErrValidation, got %v", err)
+	}
+}
+
+func TestOffsetPage_Links(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("GET", "/users?page=2&limit=10&status=active", nil)
+	q, err := ParseList(r, listCfg)
+	assertNoErr(t, err)
+	rr := httptest.NewRecorder()
+	WritePage(rr, OffsetPage(r, q, []int{11, 12}, 45))
+	assertStatus(t, http.StatusOK, rr)
+	link := rr.Header().Get("Link")
+	assertContains(t, link, `</users?limit=10&offset=20&status=active>; rel="next"`)
+	assertContains(t, link, `</users?limit=10&offset=0&status=active>; rel="prev"`)
+	assertContains(t, link, `rel="first"`)
+	assertContains(t, link, `</users?limit=10&offset=40&status=active>; rel="last"`)
+	var body Page[int]
+	assertNoErr(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	if *body.Total != 45 || body.Next != "/users?limit=10&offset=20&status=active" {
+		t.Fatalf("unexpected body: %s", rr.Body)
+	}
+}
+
+func TestOffsetPage_LastPage(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("GET", "/users?limit=10", nil)
+	q, _ := ParseList(r, listCfg)
+	p := OffsetPage[int](r, q, nil, 0)
+	if p.Next != "" || p.Prev != "" || p.Items == nil {
+		t.Fatalf("unexpected page: %+v", p)
+	}
+}
+
+func TestCursorPage_RoundTrip(t *testing.T) {
+	t.Parallel()
+	type pos struct {
+		CreatedAt string `json:"c"`
+		ID        int    `json:"i"`
+	}
+	r := httptest.NewRequest("GET", "/users?limit=2", nil)
+	q, err := ParseList(r, listCfg)
+	assertNoErr(t, err)
+	p, err := CursorPage(r, q, []string{"a", "b"}, pos{"2024-01-01", 7}, nil)
+	assertNoErr(t, err)
+	if p.Prev != "" || !strings.HasPrefix(p.Next, "/users?cursor=") {
+		t.Fatalf("unexpected links: %+v", p)
+	}
+
+	next := httptest.NewRequest("GET", p.Next, nil)
+	q, err = ParseList(next, listCfg)
+	assertNoErr(t, err)
+	var got pos
+	if !q.HasCursor() || q.Backward() || q.Cursor(&got) != nil || got.ID != 7 {
+		t.Fatalf("unexpected cursor: %+v %+v", q, got)
+	}
+
+	p, err = CursorPage(next, q, []string{"c"}, nil, pos{"2024-01-02", 8})
+	assertNoErr(t, err)
+	q, err = ParseList(httptest.NewRequest("GET", p.Prev, nil), listCfg)
+	assertNoErr(t, err)
+	if !q.Backward() {
+		t.Fatal("expected backward cursor")
+	}
+}
+
+func TestParseList_RejectsTamperedCursor(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("GET", "/users?status[in]=active,new", nil)
+	q, _ := ParseList(r, listCfg)
+	p, err := CursorPage(r, q, []int{1}, map[string]int{"id": 1}, nil)
+	assertNoErr(t, err)
+	u, _ := url.Parse(p.Next)
+	token := u.Query().Get("cursor")
+	if _, err := ParseList(httptest.NewRequest("GET", p.Next, nil), listCfg); err != nil {
+		t.Fatalf("expected next link to be accepted, got %v", err)
+	}
+
+	otherSecret := listCfg
+	otherSecret.CursorSecret = []byte("other")
+
+	tests := map[string]struct {
+		target string
+		cfg    ListConfig
+	}{
+		"garbage":        {"/users?status[in]=active,new&cursor=abc", listCfg},
+		"payload edit":   {"/users?status[in]=active,new&cursor=" + "e30" + token[strings.Index(token, "."):], listCfg},
+		"other secret":   {"/users?status[in]=active,new&cursor=" + token, otherSecret},
+		"other sort":     {"/users?status[in]=active,new&sort=name&cursor=" + token, listCfg},
+		"other filter":   {"/users?status[in]=active&cursor=" + token, listCfg},
+		"dropped filter": {"/users?cursor=" + token, listCfg},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseList(httptest.NewRequest("GET", tt.target, nil), tt.cfg)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCursorPage_RequiresSecret(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("GET", "/users", nil)
+	q, _ := ParseList(r, ListConfig{})
+	if _, err := CursorPage(r, q, []int{1}, 1, nil); err == nil {
+		t.Fatal("expected error without CursorSecret")
+	}
+}
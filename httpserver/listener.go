@@ -0,0 +1,335 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	NetworkTCP     = "tcp"
+	NetworkUnix    = "unix"
+	NetworkSystemd = "systemd"
+	NetworkFD      = "fd"
+)
+
+// sdListenFDsStart is the first descriptor passed by systemd socket
+// activation (SD_LISTEN_FDS_START).
+const sdListenFDsStart = 3
+
+// ListenerConfig describes one socket served by the Module.
+//
+//	tcp     — Address is host:port
+//	unix    — Address is the socket path; Mode, Owner and Group are
+//	          applied after bind
+//	systemd — Address is a LISTEN_FDNAMES name; empty takes every
+//	          activated socket
+//	fd      — Address is an inherited descriptor number
+type ListenerConfig struct {
+	Network string // default "tcp"
+	Address string
+	Mode    os.FileMode // unix: 0 = keep umask result
+	Owner   string      // unix: user name or uid, "" = unchanged
+	Group   string      // unix: group name or gid, "" = unchanged
+}
+
+// ListenerHandoff supplies sockets inherited from a previous process
+// and records the ones opened here so they can be passed on
+// (see upgrade.Upgrader).
+type ListenerHandoff interface {
+	Inherited(key string) net.Listener
+	Track(key string, ln net.Listener)
+}
+
+func (lc ListenerConfig) String() string {
+	network := lc.Network
+	if network == "" {
+		network = NetworkTCP
+	}
+
+	return network + "://" + lc.Address
+}
+
+func listenWithHandoff(lc ListenerConfig, h ListenerHandoff) ([]net.Listener, error) {
+	if h == nil {
+		return listen(lc)
+	}
+
+	var lns []net.Listener
+
+	for i := 0; ; i++ {
+		ln := h.Inherited(handoffKey(lc, i))
+		if ln == nil {
+			break
+		}
+
+		lns = append(lns, ln)
+	}
+
+	if len(lns) == 0 {
+		var err error
+		if lns, err = listen(lc); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, ln := range lns {
+		h.Track(handoffKey(lc, i), ln)
+	}
+
+	return lns, nil
+}
+
+// handoffKey names the i-th socket of a listener config; systemd may
+// activate several sockets under one name.
+func handoffKey(lc ListenerConfig, i int) string {
+	if i == 0 {
+		return lc.String()
+	}
+
+	return lc.String() + "#" + strconv.Itoa(i)
+}
+
+func listen(lc ListenerConfig) ([]net.Listener, error) {
+	switch lc.Network {
+	case "", NetworkTCP:
+		ln, err := net.Listen("tcp", lc.Address)
+		if err != nil {
+			return nil, fmt.Errorf("httpserver: listen %s: %w", lc.Address, err)
+		}
+
+		return []net.Listener{ln}, nil
+	case NetworkUnix:
+		ln, err := listenUnix(lc)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{ln}, nil
+	case NetworkSystemd:
+		return systemdListeners(lc.Address)
+	case NetworkFD:
+		fd, err := strconv.Atoi(lc.Address)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("httpserver: invalid fd %q", lc.Address)
+		}
+
+		ln, err := fileListener(uintptr(fd), "fd"+lc.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{ln}, nil
+	}
+
+	return nil, fmt.Errorf("httpserver: unsupported network %q", lc.Network)
+}
+
+func listenUnix(lc ListenerConfig) (net.Listener, error) {
+	if err := removeStaleSocket(lc.Address); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", lc.Address)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver: listen unix %s: %w", lc.Address, err)
+	}
+
+	if err := applySocketPerms(lc); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+
+	return ln, nil
+}
+
+// removeStaleSocket deletes a socket file left by a crashed process.
+// A socket somebody still accepts on is reported as in use.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("httpserver: stat %s: %w", path, err)
+	}
+
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("httpserver: %s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("httpserver: listen unix %s: %w", path, syscall.EADDRINUSE)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("httpserver: remove stale socket %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func applySocketPerms(lc ListenerConfig) error {
+	if lc.Mode != 0 {
+		if err := os.Chmod(lc.Address, lc.Mode); err != nil {
+			return fmt.Errorf("httpserver: chmod %s: %w", lc.Address, err)
+		}
+	}
+
+	if lc.Owner == "" && lc.Group == "" {
+		return nil
+	}
+
+	uid, gid := -1, -1
+
+	if lc.Owner != "" {
+		id, err := lookupID(lc.Owner, func(s string) (string, error) {
+			u, err := user.Lookup(s)
+			if err != nil {
+				return "", err
+			}
+
+			return u.Uid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("httpserver: socket owner %q: %w", lc.Owner, err)
+		}
+
+		uid = id
+	}
+
+	if lc.Group != "" {
+		id, err := lookupID(lc.Group, func(s string) (string, error) {
+			g, err := user.LookupGroup(s)
+			if err != nil {
+				return "", err
+			}
+
+			return g.Gid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("httpserver: socket group %q: %w", lc.Group, err)
+		}
+
+		gid = id
+	}
+
+	if err := os.Chown(lc.Address, uid, gid); err != nil {
+		return fmt.Errorf("httpserver: chown %s: %w", lc.Address, err)
+	}
+
+	return nil
+}
+
+func lookupID(name string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+
+	s, err := lookup(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(s)
+}
+
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	f := os.NewFile(fd, name)
+	if f == nil {
+		return nil, fmt.Errorf("httpserver: invalid descriptor %d", fd)
+	}
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("httpserver: listener from %s: %w", name, err)
+	}
+
+	return ln, nil
+}
+
+type activatedSocket struct {
+	name     string
+	listener net.Listener
+}
+
+// activation is parsed once: the descriptors belong to the process and
+// may only be wrapped a single time.
+var activation = sync.OnceValues(func() ([]*activatedSocket, error) {
+	return parseActivation(os.Getenv, os.Getpid())
+})
+
+func parseActivation(getenv func(string) string, pid int) ([]*activatedSocket, error) {
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	names := strings.Split(getenv("LISTEN_FDNAMES"), ":")
+	sockets := make([]*activatedSocket, 0, n)
+
+	for i := range n {
+		name := "LISTEN_FD_" + strconv.Itoa(sdListenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		ln, err := fileListener(uintptr(sdListenFDsStart+i), name)
+		if err != nil {
+			return nil, err
+		}
+
+		sockets = append(sockets, &activatedSocket{name: name, listener: ln})
+	}
+
+	return sockets, nil
+}
+
+var activationMu sync.Mutex
+
+// systemdListeners hands out activated sockets matching name; each
+// socket is given to one listener config only.
+func systemdListeners(name string) ([]net.Listener, error) {
+	sockets, err := activation()
+	if err != nil {
+		return nil, err
+	}
+
+	activationMu.Lock()
+	defer activationMu.Unlock()
+
+	var out []net.Listener
+
+	for _, s := range sockets {
+		if s.listener == nil || (name != "" && s.name != name) {
+			continue
+		}
+
+		out = append(out, s.listener)
+		s.listener = nil
+	}
+
+	if len(out) == 0 {
+		if name == "" {
+			return nil, errors.New("httpserver: no systemd activated sockets (LISTEN_FDS)")
+		}
+
+		return nil, fmt.Errorf("httpserver: no systemd activated socket named %q", name)
+	}
+
+	return out, nil
+}
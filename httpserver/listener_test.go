@@ -0,0 +1,207 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func unixClient(path string) *http.Client {
+	return &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	})
+}
+
+func getBody(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	return string(body)
+}
+
+func TestModule_MultipleListeners(t *testing.T) {
+	t.Parallel()
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	m := NewModule(protoHandler(), Config{Listeners: []ListenerConfig{
+		{Address: "127.0.0.1:0"},
+		{Network: NetworkUnix, Address: sock, Mode: 0o660},
+	}})
+	ctx := context.Background()
+	assertNoErr(t, m.Init(ctx))
+	assertNoErr(t, m.Start(ctx))
+
+	if addrs := m.Addrs(); len(addrs) != 2 || addrs[1] != sock {
+		t.Fatalf("unexpected addrs %v", addrs)
+	}
+
+	fi, err := os.Stat(sock)
+	assertNoErr(t, err)
+	if fi.Mode().Perm() != 0o660 {
+		t.Fatalf("expected mode 0660, got %v", fi.Mode().Perm())
+	}
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	if body := getBody(t, client, "http://"+m.Addr()+"/"); body != "HTTP/1.1" {
+		t.Fatalf("tcp: unexpected body %q", body)
+	}
+	if body := getBody(t, unixClient(sock), "http://unix/"); body != "HTTP/1.1" {
+		t.Fatalf("unix: unexpected body %q", body)
+	}
+
+	assertNoErr(t, m.Stop(ctx))
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatalf("expected socket file removed, got %v", err)
+	}
+}
+
+func TestListenUnix_StaleAndBusy(t *testing.T) {
+	t.Parallel()
+	sock := filepath.Join(t.TempDir(), "app.sock")
+
+	busy, err := net.Listen("unix", sock)
+	assertNoErr(t, err)
+	busy.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if _, err := listen(ListenerConfig{Network: NetworkUnix, Address: sock}); err == nil {
+		t.Fatal("expected error for socket in use")
+	}
+
+	_ = busy.Close()
+	ln, err := listen(ListenerConfig{Network: NetworkUnix, Address: sock})
+	assertNoErr(t, err)
+	_ = ln[0].Close()
+
+	regular := filepath.Join(t.TempDir(), "file")
+	writeFile(t, regular, nil)
+	if _, err := listen(ListenerConfig{Network: NetworkUnix, Address: regular}); err == nil {
+		t.Fatal("expected error for non-socket file")
+	}
+}
+
+func TestModule_H2C(t *testing.T) {
+	t.Parallel()
+	m := NewModule(protoHandler(), Config{Host: "127.0.0.1", H2C: true})
+	ctx := context.Background()
+	assertNoErr(t, m.Init(ctx))
+	assertNoErr(t, m.Start(ctx))
+	t.Cleanup(func() { _ = m.Stop(ctx) })
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: &http.Transport{Protocols: protocols},
+	}
+	if body := getBody(t, client, "http://"+m.Addr()+"/"); body != "HTTP/2.0" {
+		t.Fatalf("expected HTTP/2 cleartext, got %q", body)
+	}
+
+	plain := &http.Client{Timeout: 2 * time.Second}
+	if body := getBody(t, plain, "http://"+m.Addr()+"/"); body != "HTTP/1.1" {
+		t.Fatalf("expected HTTP/1.1 fallback, got %q", body)
+	}
+}
+
+func TestParseActivation(t *testing.T) {
+	t.Parallel()
+	env := map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "2"}
+	sockets, err := parseActivation(func(k string) string { return env[k] }, 2)
+	assertNoErr(t, err)
+	if sockets != nil {
+		t.Fatal("expected activation for another pid to be ignored")
+	}
+
+	if _, err := systemdListeners("web"); err == nil {
+		t.Fatal("expected error without LISTEN_FDS")
+	}
+}
+
+func TestListen_InvalidConfig(t *testing.T) {
+	t.Parallel()
+	for _, lc := range []ListenerConfig{
+		{Network: "sctp", Address: "x"},
+		{Network: NetworkFD, Address: "abc"},
+		{Network: NetworkUnix, Address: filepath.Join(t.TempDir(), "s.sock"), Owner: "no-such-user-xyz"},
+	} {
+		if _, err := listen(lc); err == nil {
+			t.Errorf("%s: expected error", lc)
+		}
+	}
+}
+
+func TestModule_TLS_UnixListener(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server", false)
+	writeFile(t, filepath.Join(dir, "cert.pem"), certPEM)
+	writeFile(t, filepath.Join(dir, "key.pem"), keyPEM)
+	sock := filepath.Join(dir, "tls.sock")
+
+	m := NewModule(protoHandler(), Config{
+		Listeners: []ListenerConfig{{Network: NetworkUnix, Address: sock}},
+		TLS: &TLSConfig{
+			CertFile: filepath.Join(dir, "cert.pem"),
+			KeyFile:  filepath.Join(dir, "key.pem"),
+		},
+	})
+	ctx := context.Background()
+	assertNoErr(t, m.Init(ctx))
+	assertNoErr(t, m.Start(ctx))
+	t.Cleanup(func() { _ = m.Stop(ctx) })
+
+	conn, err := tls.Dial("unix", sock, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec // test
+	assertNoErr(t, err)
+	_ = conn.Close()
+}
+
+type mapHandoff struct {
+	inherited map[string]net.Listener
+	tracked   []string
+}
+
+func (h *mapHandoff) Inherited(key string) net.Listener { return h.inherited[key] }
+
+func (h *mapHandoff) Track(key string, _ net.Listener) { h.tracked = append(h.tracked, key) }
+
+func TestModule_Handoff(t *testing.T) {
+	t.Parallel()
+	prev, err := net.Listen("tcp", "127.0.0.1:0")
+	assertNoErr(t, err)
+
+	h := &mapHandoff{inherited: map[string]net.Listener{"tcp://127.0.0.1:8080": prev}}
+	m := NewModule(protoHandler(), Config{Host: "127.0.0.1", Port: 8080, Handoff: h})
+	ctx := context.Background()
+	assertNoErr(t, m.Init(ctx))
+	assertNoErr(t, m.Start(ctx))
+	t.Cleanup(func() { _ = m.Stop(ctx) })
+
+	if m.Addr() != prev.Addr().String() {
+		t.Fatalf("expected inherited listener %s, got %s", prev.Addr(), m.Addr())
+	}
+	if len(h.tracked) != 1 || h.tracked[0] != "tcp://127.0.0.1:8080" {
+		t.Fatalf("unexpected tracked keys %v", h.tracked)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package httpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestModule_InheritedFD(t *testing.T) {
+	t.Parallel()
+	parent, err := net.Listen("tcp", "127.0.0.1:0")
+	assertNoErr(t, err)
+	f, err := parent.(*net.TCPListener).File()
+	assertNoErr(t, err)
+	// The module takes ownership of fd, as it would after exec.
+	fd, err := syscall.Dup(int(f.Fd()))
+	assertNoErr(t, err)
+	_ = f.Close()
+	_ = parent.Close()
+
+	m := NewModule(protoHandler(), Config{Listeners: []ListenerConfig{
+		{Network: NetworkFD, Address: strconv.Itoa(fd)},
+	}})
+	ctx := context.Background()
+	assertNoErr(t, m.Init(ctx))
+	assertNoErr(t, m.Start(ctx))
+	t.Cleanup(func() { _ = m.Stop(ctx) })
+
+	if body := getBody(t, &http.Client{Timeout: 2 * time.Second}, "http://"+m.Addr()+"/"); body != "HTTP/1.1" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
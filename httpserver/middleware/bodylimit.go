@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = newLimitedBody(w, r.Body, r.ContentLength, maxBytes)
+			} else if r.ContentLength > maxBytes {
+				httpserver.Error(w, ErrBodyTooLarge)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitedBody remembers the unlimited source so that a nested
+// BodyLimit (per route or group) can replace the outer limit
+// instead of being capped by it. The declared Content-Length is
+// checked on the first Read, so only the innermost limit decides.
+type limitedBody struct {
+	io.ReadCloser
+	src      io.ReadCloser
+	declared int64
+	max      int64
+}
+
+func newLimitedBody(
+	w http.ResponseWriter, body io.ReadCloser, declared, maxBytes int64,
+) *limitedBody {
+	src := body
+	if lb, ok := body.(*limitedBody); ok {
+		src = lb.src
+	}
+
+	return &limitedBody{
+		ReadCloser: http.MaxBytesReader(w, src, maxBytes),
+		src:        src,
+		declared:   declared,
+		max:        maxBytes,
+	}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.declared > b.max {
+		return 0, &http.MaxBytesError{Limit: b.max}
+	}
+
+	return b.ReadCloser.Read(p)
+}
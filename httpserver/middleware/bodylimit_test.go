@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func TestBodyLimit_RejectsByContentLength(t *testing.T) {
+	t.Parallel()
+	handler := BodyLimit(4)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var v map[string]any
+			if err := httpserver.Bind(r, &v); err != nil {
+				httpserver.Error(w, err)
+			}
+		}),
+	)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":"too long"}`))
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rr.Code)
+	}
+}
+
+func TestBodyLimit_DeclaredLengthFailsFirstRead(t *testing.T) {
+	t.Parallel()
+	var (
+		n       int
+		readErr error
+	)
+	handler := BodyLimit(4)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			n, readErr = r.Body.Read(make([]byte, 16))
+		}),
+	)
+	req := httptest.NewRequest("POST", "/", strings.NewReader("too long"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if n != 0 || !errors.As(readErr, &maxErr) {
+		t.Fatalf("expected MaxBytesError before reading, got %d, %v", n, readErr)
+	}
+}
+
+func TestBodyLimit_LimitsStreamingBody(t *testing.T) {
+	t.Parallel()
+	var readErr error
+	handler := BodyLimit(4)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			_, readErr = io.ReadAll(r.Body)
+		}),
+	)
+	req := httptest.NewRequest("POST", "/", strings.NewReader("too long"))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Fatalf("expected MaxBytesError, got %v", readErr)
+	}
+}
+
+func TestBodyLimit_InnerLimitOverridesOuter(t *testing.T) {
+	t.Parallel()
+	var got string
+	inner := BodyLimit(64)(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			got = string(b)
+		}),
+	)
+	handler := BodyLimit(4)(inner)
+	req := httptest.NewRequest("POST", "/", strings.NewReader("longer than four"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "longer than four" {
+		t.Fatalf("expected full body, got %q", got)
+	}
+}
+
+func TestBodyLimit_NoBody(t *testing.T) {
+	t.Parallel()
+	handler := BodyLimit(4)(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+}
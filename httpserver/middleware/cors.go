@@ -7,74 +7,232 @@ import (
 )
 
 type CORSConfig struct {
-	AllowedOrigins []string
-	AllowedMethods []string
-	AllowedHeaders []string
-	MaxAge         int // seconds
+	// AllowedOrigins accepts exact origins, "*" and single-wildcard
+	// patterns such as "https://*.example.com".
+	AllowedOrigins      []string
+	AllowOriginFunc     func(origin string) bool
+	AllowedMethods      []string // default: GET, HEAD, POST
+	AllowedHeaders      []string // "*" reflects the requested headers
+	ExposedHeaders      []string
+	AllowCredentials    bool
+	AllowPrivateNetwork bool
+	MaxAge              int // seconds
+}
+
+type corsPolicy struct {
+	cfg        CORSConfig
+	allowAll   bool
+	exact      map[string]struct{}
+	patterns   [][2]string
+	methods    []string
+	anyHeader  bool
+	headers    map[string]struct{}
+	methodList string
+	exposed    string
+	maxAge     string
 }
 
 func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
-	methods := strings.Join(cfg.AllowedMethods, ", ")
-	headers := strings.Join(cfg.AllowedHeaders, ", ")
-	maxAge := strconv.Itoa(cfg.MaxAge)
+	p := newCORSPolicy(cfg)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-			if origin == "" {
-				next.ServeHTTP(w, r)
+			if isPreflight(r) {
+				p.handlePreflight(w, r)
 				return
 			}
 
-			matched, allowOrigin := matchOrigin(origin, cfg.AllowedOrigins)
-			if !matched {
-				next.ServeHTTP(w, r)
-				return
-			}
+			p.handleActual(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func newCORSPolicy(cfg CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		cfg:     cfg,
+		exact:   make(map[string]struct{}),
+		headers: make(map[string]struct{}),
+		methods: cfg.AllowedMethods,
+		exposed: strings.Join(cfg.ExposedHeaders, ", "),
+		maxAge:  strconv.Itoa(cfg.MaxAge),
+	}
 
-			setCORSHeaders(w, allowOrigin, methods, headers, maxAge)
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(o)
+		switch {
+		case o == "*":
+			p.allowAll = true
+		case strings.Count(o, "*") == 1:
+			prefix, suffix, _ := strings.Cut(o, "*")
+			p.patterns = append(p.patterns, [2]string{prefix, suffix})
+		default:
+			p.exact[o] = struct{}{}
+		}
+	}
 
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusNoContent)
-				return
-			}
+	if p.allowAll && cfg.AllowCredentials {
+		panic(`middleware: CORS AllowedOrigins "*" cannot be combined with AllowCredentials; ` +
+			"list the origins or use AllowOriginFunc")
+	}
 
-			next.ServeHTTP(w, r)
-		})
+	if len(p.methods) == 0 {
+		p.methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
 	}
+
+	p.methodList = strings.Join(p.methods, ", ")
+
+	for _, h := range cfg.AllowedHeaders {
+		if h == "*" {
+			p.anyHeader = true
+		}
+
+		p.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	return p
 }
 
-func matchOrigin(
-	origin string, allowed []string,
-) (bool, string) {
-	for _, a := range allowed {
-		if a == "*" {
-			return true, "*"
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+func (p *corsPolicy) handleActual(w http.ResponseWriter, r *http.Request) {
+	p.addVary(w, "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !p.originAllowed(origin) {
+		return
+	}
+
+	p.setAllowOrigin(w, origin)
+
+	if p.exposed != "" {
+		w.Header().Set("Access-Control-Expose-Headers", p.exposed)
+	}
+}
+
+func (p *corsPolicy) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	p.addVary(w,
+		"Origin",
+		"Access-Control-Request-Method",
+		"Access-Control-Request-Headers",
+	)
+
+	origin := r.Header.Get("Origin")
+	method := r.Header.Get("Access-Control-Request-Method")
+	reqHeaders := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+
+	if !p.originAllowed(origin) ||
+		!p.methodAllowed(method) ||
+		!p.headersAllowed(reqHeaders) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	p.setAllowOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", p.methodList)
+
+	if len(reqHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+	}
+
+	if p.cfg.AllowPrivateNetwork &&
+		r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+		w.Header().Set("Access-Control-Allow-Private-Network", "true")
+	}
+
+	if p.cfg.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", p.maxAge)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setAllowOrigin answers "*" only for public policies; newCORSPolicy
+// rejects "*" with credentials.
+func (p *corsPolicy) setAllowOrigin(w http.ResponseWriter, origin string) {
+	if p.allowAll {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if p.cfg.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *corsPolicy) addVary(w http.ResponseWriter, headers ...string) {
+	if p.allowAll {
+		return
+	}
+
+	for _, h := range headers {
+		w.Header().Add("Vary", h)
+	}
+}
+
+func (p *corsPolicy) originAllowed(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+
+	o := strings.ToLower(origin)
+	if _, ok := p.exact[o]; ok {
+		return true
+	}
+
+	for _, pat := range p.patterns {
+		if len(o) > len(pat[0])+len(pat[1]) &&
+			strings.HasPrefix(o, pat[0]) &&
+			strings.HasSuffix(o, pat[1]) {
+			return true
 		}
+	}
 
-		if a == origin {
-			return true, origin
+	return p.cfg.AllowOriginFunc != nil && p.cfg.AllowOriginFunc(origin)
+}
+
+func (p *corsPolicy) methodAllowed(method string) bool {
+	for _, m := range p.methods {
+		if strings.EqualFold(m, method) {
+			return true
 		}
 	}
 
-	return false, ""
+	return false
 }
 
-func setCORSHeaders(
-	w http.ResponseWriter,
-	origin, methods, headers, maxAge string,
-) {
-	w.Header().Set("Access-Control-Allow-Origin", origin)
+func (p *corsPolicy) headersAllowed(requested []string) bool {
+	if p.anyHeader {
+		return true
+	}
 
-	if methods != "" {
-		w.Header().Set("Access-Control-Allow-Methods", methods)
+	for _, h := range requested {
+		if _, ok := p.headers[http.CanonicalHeaderKey(h)]; !ok {
+			return false
+		}
 	}
 
-	if headers != "" {
-		w.Header().Set("Access-Control-Allow-Headers", headers)
+	return true
+}
+
+func parseHeaderList(v string) []string {
+	if v == "" {
+		return nil
 	}
 
-	if maxAge != "0" {
-		w.Header().Set("Access-Control-Max-Age", maxAge)
+	parts := strings.Split(v, ",")
+	out := make([]string, 0, len(parts))
+
+	for _, part := range parts {
+		if h := strings.TrimSpace(part); h != "" {
+			out = append(out, h)
+		}
 	}
+
+	return out
 }
@@ -16,8 +16,8 @@ func TestCORS_AllowsMatchingOrigin(t *testing.T) {
 	}
 	handler := CORS(cfg)(okHandler())
 	rr := httptest.NewRecorder()
-	req := httptest.NewRequest("GET", "/", nil)
-	req.Header.Set("Origin", "https://example.com")
+	req := preflightRequest("https://example.com", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
 	handler.ServeHTTP(rr, req)
 	origin := rr.Header().Get("Access-Control-Allow-Origin")
 	if origin != "https://example.com" {
@@ -46,12 +46,13 @@ func TestCORS_Preflight(t *testing.T) {
 	cfg := CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"POST"}}
 	handler := CORS(cfg)(okHandler())
 	rr := httptest.NewRecorder()
-	req := httptest.NewRequest("OPTIONS", "/", nil)
-	req.Header.Set("Origin", "https://example.com")
-	handler.ServeHTTP(rr, req)
+	handler.ServeHTTP(rr, preflightRequest("https://example.com", "POST"))
 	if rr.Code != http.StatusNoContent {
 		t.Fatalf("expected 204, got %d", rr.Code)
 	}
+	if v := rr.Header().Get("Access-Control-Allow-Methods"); v != "POST" {
+		t.Fatalf("expected 'POST', got %q", v)
+	}
 }
 
 func TestCORS_NoOriginHeader(t *testing.T) {
@@ -110,6 +111,190 @@ func TestCORS_NoMethodsOrHeaders(t *testing.T) {
 	}
 }
 
+func TestCORS_OptionsWithoutPreflightPassesThrough(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected handler response 200, got %d", rr.Code)
+	}
+}
+
+func TestCORS_PreflightDisallowedMethod(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, preflightRequest("https://example.com", "DELETE"))
+	if v := rr.Header().Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("expected no CORS header, got %q", v)
+	}
+}
+
+func TestCORS_PreflightDisallowedHeader(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"Content-Type"}}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := preflightRequest("https://example.com", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "X-Secret")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Access-Control-Allow-Origin"); v != "" {
+		t.Fatalf("expected no CORS header, got %q", v)
+	}
+}
+
+func TestCORS_WildcardHeadersReflected(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := preflightRequest("https://example.com", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "X-One, X-Two")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Access-Control-Allow-Headers"); v != "X-One, X-Two" {
+		t.Fatalf("expected reflected headers, got %q", v)
+	}
+}
+
+func TestCORS_CredentialsWithWildcardPanics(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for \"*\" with credentials")
+		}
+	}()
+	CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestCORS_CredentialsWithOriginFunc(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{
+		AllowOriginFunc:  func(origin string) bool { return origin == "https://app.com" },
+		AllowCredentials: true,
+	}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://app.com")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Access-Control-Allow-Origin"); v != "https://app.com" {
+		t.Fatalf("expected reflected origin, got %q", v)
+	}
+	if v := rr.Header().Get("Access-Control-Allow-Credentials"); v != "true" {
+		t.Fatalf("expected credentials true, got %q", v)
+	}
+	if v := rr.Header().Get("Vary"); v != "Origin" {
+		t.Fatalf("expected Vary: Origin, got %q", v)
+	}
+}
+
+func TestCORS_ExposedHeaders(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{
+		AllowedOrigins: []string{"https://app.com"},
+		ExposedHeaders: []string{"X-Request-Id", "ETag"},
+	}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://app.com")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Access-Control-Expose-Headers"); v != "X-Request-Id, ETag" {
+		t.Fatalf("unexpected exposed headers: %q", v)
+	}
+}
+
+func TestCORS_VaryOnExplicitOrigins(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"https://allowed.com"}}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if v := rr.Header().Get("Vary"); v != "Origin" {
+		t.Fatalf("expected Vary: Origin, got %q", v)
+	}
+}
+
+func TestCORS_NoVaryForPublicWildcard(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://any.com")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Vary"); v != "" {
+		t.Fatalf("expected no Vary, got %q", v)
+	}
+}
+
+func TestCORS_SubdomainPattern(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}
+	handler := CORS(cfg)(okHandler())
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"https://.example.com", false},
+		{"http://app.example.com", false},
+		{"https://example.com.evil.com", false},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", tt.origin)
+		handler.ServeHTTP(rr, req)
+		got := rr.Header().Get("Access-Control-Allow-Origin") != ""
+		if got != tt.allowed {
+			t.Errorf("%s: expected allowed=%v", tt.origin, tt.allowed)
+		}
+	}
+}
+
+func TestCORS_AllowOriginFunc(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{
+		AllowOriginFunc: func(origin string) bool { return origin == "https://dyn.com" },
+	}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://dyn.com")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Access-Control-Allow-Origin"); v != "https://dyn.com" {
+		t.Fatalf("expected origin allowed by func, got %q", v)
+	}
+}
+
+func TestCORS_PrivateNetwork(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{AllowedOrigins: []string{"*"}, AllowPrivateNetwork: true}
+	handler := CORS(cfg)(okHandler())
+	rr := httptest.NewRecorder()
+	req := preflightRequest("https://public.com", "GET")
+	req.Header.Set("Access-Control-Request-Private-Network", "true")
+	handler.ServeHTTP(rr, req)
+	if v := rr.Header().Get("Access-Control-Allow-Private-Network"); v != "true" {
+		t.Fatalf("expected private network allowed, got %q", v)
+	}
+}
+
+func preflightRequest(origin, method string) *http.Request {
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	return req
+}
+
 func okHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
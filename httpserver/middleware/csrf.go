@@ -0,0 +1,298 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+// CSRFStore keeps the expected token. The default cookie store gives
+// the double-submit cookie pattern; a session-backed store gives the
+// synchronizer token pattern.
+type CSRFStore interface {
+	Load(r *http.Request) (string, error)
+	Save(w http.ResponseWriter, r *http.Request, token string) error
+}
+
+type CSRFConfig struct {
+	// Secret signs issued tokens. Alone it only proves the token came
+	// from this server; with SessionID the signature is bound to the
+	// session, so a token planted from another session is rejected.
+	Secret []byte
+	// SessionID returns the session the token belongs to, e.g. the
+	// session cookie value; "" for anonymous requests.
+	SessionID      func(r *http.Request) string
+	Store          CSRFStore // nil = double-submit cookie
+	HeaderName     string    // default X-CSRF-Token
+	FormField      string    // default csrf_token
+	TrustedOrigins []string  // extra origins allowed for unsafe methods
+	// ExemptPaths are skipped entirely: "/webhooks" matches /webhooks and
+	// /webhooks/stripe but not /webhooks-admin. For a whole route group
+	// use Router.Without(csrf) instead.
+	ExemptPaths []string
+
+	CookieName     string // default csrf_token
+	CookiePath     string // default /
+	CookieDomain   string
+	CookieMaxAge   int // seconds, 0 = session cookie
+	CookieInsecure bool
+	CookieSameSite http.SameSite // default Lax
+}
+
+func (c CSRFConfig) withDefaults() CSRFConfig {
+	if c.HeaderName == "" {
+		c.HeaderName = "X-CSRF-Token"
+	}
+
+	if c.FormField == "" {
+		c.FormField = "csrf_token"
+	}
+
+	if c.CookieName == "" {
+		c.CookieName = "csrf_token"
+	}
+
+	if c.CookiePath == "" {
+		c.CookiePath = "/"
+	}
+
+	if c.CookieSameSite == 0 {
+		c.CookieSameSite = http.SameSiteLaxMode
+	}
+
+	if c.Store == nil {
+		c.Store = &cookieCSRFStore{cfg: c}
+	}
+
+	return c
+}
+
+type csrfTokenKey struct{}
+
+func CSRF(cfg CSRFConfig) func(http.Handler) http.Handler {
+	cfg = cfg.withDefaults()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isCSRFExempt(cfg, r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := ensureCSRFToken(cfg, w, r)
+			if err != nil {
+				httpserver.Error(w, err)
+				return
+			}
+
+			if !isSafeMethod(r.Method) {
+				if err := verifyCSRF(cfg, r, token); err != nil {
+					httpserver.Error(w, err)
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), csrfTokenKey{}, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey{}).(string)
+	return token
+}
+
+func ensureCSRFToken(
+	cfg CSRFConfig, w http.ResponseWriter, r *http.Request,
+) (string, error) {
+	token, err := cfg.Store.Load(r)
+	if err != nil {
+		return "", err
+	}
+
+	session := cfg.session(r)
+
+	if token != "" && validTokenSignature(cfg.Secret, session, token) {
+		return token, nil
+	}
+
+	token = newCSRFToken(cfg.Secret, session)
+	if err := cfg.Store.Save(w, r, token); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func verifyCSRF(cfg CSRFConfig, r *http.Request, expected string) error {
+	if err := checkRequestOrigin(cfg, r); err != nil {
+		return err
+	}
+
+	submitted := r.Header.Get(cfg.HeaderName)
+	if submitted == "" && isFormRequest(r) {
+		submitted = r.PostFormValue(cfg.FormField)
+	}
+
+	if submitted == "" {
+		return ErrCSRFTokenMissing
+	}
+
+	if subtle.ConstantTimeCompare([]byte(submitted), []byte(expected)) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+
+	return nil
+}
+
+func checkRequestOrigin(cfg CSRFConfig, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return ErrCSRFOriginMismatch
+	}
+
+	if strings.EqualFold(u.Host, r.Host) && strings.EqualFold(u.Scheme, requestScheme(r)) {
+		return nil
+	}
+
+	base := u.Scheme + "://" + u.Host
+	for _, trusted := range cfg.TrustedOrigins {
+		if strings.EqualFold(trusted, base) {
+			return nil
+		}
+	}
+
+	return ErrCSRFOriginMismatch
+}
+
+// requestScheme honours X-Forwarded-Proto from a TLS-terminating proxy;
+// a cross-site browser request cannot set it.
+func requestScheme(r *http.Request) string {
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		proto, _, _ = strings.Cut(proto, ",")
+		return strings.TrimSpace(proto)
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+func (c CSRFConfig) session(r *http.Request) string {
+	if c.SessionID == nil {
+		return ""
+	}
+
+	return c.SessionID(r)
+}
+
+func isCSRFExempt(cfg CSRFConfig, r *http.Request) bool {
+	for _, prefix := range cfg.ExemptPaths {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead,
+		http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFormRequest(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+
+	return strings.HasPrefix(ct, "application/x-www-form-urlencoded") ||
+		strings.HasPrefix(ct, "multipart/form-data")
+}
+
+func newCSRFToken(secret []byte, session string) string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if len(secret) == 0 {
+		return token
+	}
+
+	return token + "." + signToken(secret, session, token)
+}
+
+func validTokenSignature(secret []byte, session, token string) bool {
+	if len(secret) == 0 {
+		return true
+	}
+
+	value, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	return hmac.Equal([]byte(sig), []byte(signToken(secret, session, value)))
+}
+
+func signToken(secret []byte, session, value string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(session))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type cookieCSRFStore struct {
+	cfg CSRFConfig
+}
+
+func (s *cookieCSRFStore) Load(r *http.Request) (string, error) {
+	c, err := r.Cookie(s.cfg.CookieName)
+	if err != nil {
+		return "", nil
+	}
+
+	return c.Value, nil
+}
+
+func (s *cookieCSRFStore) Save(
+	w http.ResponseWriter, _ *http.Request, token string,
+) error {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cfg.CookieName,
+		Value:    token,
+		Path:     s.cfg.CookiePath,
+		Domain:   s.cfg.CookieDomain,
+		MaxAge:   s.cfg.CookieMaxAge,
+		Secure:   !s.cfg.CookieInsecure,
+		SameSite: s.cfg.CookieSameSite,
+	})
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func TestCSRF_SafeMethodIssuesToken(t *testing.T) {
+	t.Parallel()
+	var token string
+	handler := CSRF(CSRFConfig{})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			token = CSRFToken(r.Context())
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if token == "" {
+		t.Fatal("expected token in context")
+	}
+	cookie := findCookie(rr, "csrf_token")
+	if cookie == nil || cookie.Value != token {
+		t.Fatalf("expected cookie with token, got %+v", cookie)
+	}
+	if !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("unexpected cookie attributes: %+v", cookie)
+	}
+}
+
+func TestCSRF_UnsafeWithoutToken(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{})(okHandler())
+	req := httptest.NewRequest("POST", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "CSRF_TOKEN_MISSING") {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestCSRF_HeaderTokenMatches(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{})(okHandler())
+	req := httptest.NewRequest("POST", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+	req.Header.Set("X-CSRF-Token", "abc")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+}
+
+func TestCSRF_TokenMismatch(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{})(okHandler())
+	req := httptest.NewRequest("POST", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+	req.Header.Set("X-CSRF-Token", "xyz")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if !strings.Contains(rr.Body.String(), "CSRF_TOKEN_INVALID") {
+		t.Fatalf("expected invalid token error, got %s", rr.Body.String())
+	}
+}
+
+func TestCSRF_FormField(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{})(okHandler())
+	form := url.Values{"csrf_token": {"abc"}}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+}
+
+func TestCSRF_OriginMismatch(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{})(okHandler())
+	req := httptest.NewRequest("POST", "http://app.com/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+	req.Header.Set("X-CSRF-Token", "abc")
+	req.Header.Set("Origin", "https://evil.com")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if !strings.Contains(rr.Body.String(), "CSRF_ORIGIN_MISMATCH") {
+		t.Fatalf("expected origin mismatch, got %s", rr.Body.String())
+	}
+}
+
+func TestCSRF_TrustedOriginAndReferer(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{
+		TrustedOrigins: []string{"https://admin.app.com"},
+	})(okHandler())
+	for _, hdr := range []string{"Origin", "Referer"} {
+		req := httptest.NewRequest("POST", "http://app.com/", nil)
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+		req.Header.Set("X-CSRF-Token", "abc")
+		req.Header.Set(hdr, "https://admin.app.com/page")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", hdr, rr.Code)
+		}
+	}
+}
+
+func TestCSRF_SignedTokens(t *testing.T) {
+	t.Parallel()
+	cfg := CSRFConfig{Secret: []byte("secret")}
+	handler := CSRF(cfg)(okHandler())
+	req := httptest.NewRequest("POST", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "forged"})
+	req.Header.Set("X-CSRF-Token", "forged")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected forged token rejected, got %d", rr.Code)
+	}
+	token := newCSRFToken(cfg.Secret, "")
+	req = httptest.NewRequest("POST", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+	req.Header.Set("X-CSRF-Token", token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected signed token accepted, got %d", rr.Code)
+	}
+}
+
+func TestCSRF_TokenBoundToSession(t *testing.T) {
+	t.Parallel()
+	cfg := CSRFConfig{
+		Secret: []byte("secret"),
+		SessionID: func(r *http.Request) string {
+			c, err := r.Cookie("session")
+			if err != nil {
+				return ""
+			}
+			return c.Value
+		},
+	}
+	handler := CSRF(cfg)(okHandler())
+	post := func(session, token string) int {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: session})
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+		req.Header.Set("X-CSRF-Token", token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	attacker := newCSRFToken(cfg.Secret, "attacker")
+	if code := post("victim", attacker); code != http.StatusForbidden {
+		t.Fatalf("expected token from another session rejected, got %d", code)
+	}
+	if code := post("victim", newCSRFToken(cfg.Secret, "victim")); code != http.StatusOK {
+		t.Fatalf("expected own session token accepted, got %d", code)
+	}
+}
+
+func TestCSRF_OriginSchemeMustMatch(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{})(okHandler())
+	request := func(origin, forwardedProto string) int {
+		req := httptest.NewRequest("POST", "http://app.com/", nil)
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "abc"})
+		req.Header.Set("X-CSRF-Token", "abc")
+		req.Header.Set("Origin", origin)
+		if forwardedProto != "" {
+			req.Header.Set("X-Forwarded-Proto", forwardedProto)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := request("http://app.com", "https"); code != http.StatusForbidden {
+		t.Fatalf("expected http origin rejected on https site, got %d", code)
+	}
+	if code := request("https://app.com", "https"); code != http.StatusOK {
+		t.Fatalf("expected https origin accepted, got %d", code)
+	}
+	if code := request("http://app.com", ""); code != http.StatusOK {
+		t.Fatalf("expected same-scheme origin accepted, got %d", code)
+	}
+}
+
+func TestCSRF_ExemptPaths(t *testing.T) {
+	t.Parallel()
+	handler := CSRF(CSRFConfig{ExemptPaths: []string{"/webhooks"}})(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/webhooks/stripe", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/webhooks-admin", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 outside the exempt segment, got %d", rr.Code)
+	}
+}
+
+func TestCSRF_ExemptGroup(t *testing.T) {
+	t.Parallel()
+	csrf := CSRF(CSRFConfig{})
+	router := httpserver.NewRouter()
+	router.Use(csrf)
+	router.Without(csrf).Group("/webhooks").POST("/stripe", okHandler().ServeHTTP)
+	router.POST("/orders", okHandler().ServeHTTP)
+
+	for path, want := range map[string]int{
+		"/webhooks/stripe": http.StatusOK,
+		"/orders":          http.StatusForbidden,
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", path, nil))
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rr.Code)
+		}
+	}
+}
+
+func TestCSRF_SynchronizerStore(t *testing.T) {
+	t.Parallel()
+	store := &memoryCSRFStore{token: "session-token"}
+	handler := CSRF(CSRFConfig{Store: store})(okHandler())
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-CSRF-Token", "session-token")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if findCookie(rr, "csrf_token") != nil {
+		t.Fatal("store-backed mode must not set a cookie")
+	}
+}
+
+type memoryCSRFStore struct {
+	token string
+}
+
+func (s *memoryCSRFStore) Load(_ *http.Request) (string, error) { return s.token, nil }
+
+func (s *memoryCSRFStore) Save(_ http.ResponseWriter, _ *http.Request, token string) error {
+	s.token = token
+	return nil
+}
+
+func findCookie(rr *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
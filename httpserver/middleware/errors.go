@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	domainerrors "github.com/shuldan/errors"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+var ErrTimeout = domainerrors.NewCode("REQUEST_TIMEOUT").
+	Kind(domainerrors.Infrastructure).
+	New("request timed out")
+
+var ErrBodyTooLarge = httpserver.ErrBodyTooLarge
+
+var ErrHeadersTooLarge = domainerrors.NewCode("HEADERS_TOO_LARGE").
+	Kind(domainerrors.Validation).
+	New("request headers too large")
+
+var ErrCSRFTokenMissing = domainerrors.NewCode("CSRF_TOKEN_MISSING").
+	Kind(domainerrors.Authorization).
+	New("CSRF token missing")
+
+var ErrCSRFTokenInvalid = domainerrors.NewCode("CSRF_TOKEN_INVALID").
+	Kind(domainerrors.Authorization).
+	New("CSRF token invalid")
+
+var ErrCSRFOriginMismatch = domainerrors.NewCode("CSRF_ORIGIN_MISMATCH").
+	Kind(domainerrors.Authorization).
+	New("request origin not allowed")
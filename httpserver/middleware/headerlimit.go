@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+type HeaderLimitConfig struct {
+	MaxCount int // number of header values, 0 = unlimited
+	MaxBytes int // total size of names and values, 0 = unlimited
+}
+
+func HeaderLimit(cfg HeaderLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exceedsHeaderLimit(r.Header, cfg) {
+				httpserver.ErrorStatus(
+					w,
+					http.StatusRequestHeaderFieldsTooLarge,
+					ErrHeadersTooLarge,
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func exceedsHeaderLimit(h http.Header, cfg HeaderLimitConfig) bool {
+	count, size := 0, 0
+
+	for name, values := range h {
+		for _, v := range values {
+			count++
+			size += len(name) + len(v)
+		}
+	}
+
+	if cfg.MaxCount > 0 && count > cfg.MaxCount {
+		return true
+	}
+
+	return cfg.MaxBytes > 0 && size > cfg.MaxBytes
+}
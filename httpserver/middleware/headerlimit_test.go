@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHeaderLimit_TooManyHeaders(t *testing.T) {
+	t.Parallel()
+	handler := HeaderLimit(HeaderLimitConfig{MaxCount: 2})(okHandler())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("A", "1")
+	req.Header.Set("B", "2")
+	req.Header.Set("C", "3")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("expected 431, got %d", rr.Code)
+	}
+}
+
+func TestHeaderLimit_TooLarge(t *testing.T) {
+	t.Parallel()
+	handler := HeaderLimit(HeaderLimitConfig{MaxBytes: 32})(okHandler())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Big", strings.Repeat("x", 64))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("expected 431, got %d", rr.Code)
+	}
+}
+
+func TestHeaderLimit_WithinLimits(t *testing.T) {
+	t.Parallel()
+	handler := HeaderLimit(HeaderLimitConfig{MaxCount: 5, MaxBytes: 128})(okHandler())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Small", "ok")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+}
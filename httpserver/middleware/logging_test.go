@@ -1,9 +1,14 @@
 package middleware
 
 import (
+	"bufio"
+	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/shuldan/framework/httpserver"
 )
 
 type mockLogger struct {
@@ -76,6 +81,67 @@ func TestStatusWriter_Unwrap(t *testing.T) {
 	}
 }
 
+func TestStatusWriter_Flush(t *testing.T) {
+	t.Parallel()
+	rr := httptest.NewRecorder()
+	var w http.ResponseWriter = &statusWriter{ResponseWriter: rr, status: http.StatusOK}
+	f, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("statusWriter must implement http.Flusher")
+	}
+	f.Flush()
+	if !rr.Flushed {
+		t.Fatal("flush not propagated")
+	}
+}
+
+func TestLogging_SSE(t *testing.T) {
+	t.Parallel()
+	log := &mockLogger{}
+	handler := Logging(log)(httpserver.SSE(httpserver.SSEConfig{Heartbeat: -1},
+		func(_ context.Context, s *httpserver.SSEStream) error {
+			return s.Send(httpserver.Event{Data: "hi"})
+		}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/events", nil))
+	if rr.Body.String() != "data: hi\n\n" || !rr.Flushed {
+		t.Fatalf("unexpected stream: %q", rr.Body.String())
+	}
+	if findKV(log.args, "status") != http.StatusOK {
+		t.Fatalf("unexpected status: %v", findKV(log.args, "status"))
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestLogging_Hijack(t *testing.T) {
+	t.Parallel()
+	log := &mockLogger{}
+	handler := Logging(log)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if _, _, err := http.NewResponseController(w).Hijack(); err != nil {
+				t.Errorf("hijack through Logging failed: %v", err)
+			}
+		}),
+	)
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+	if !rec.hijacked {
+		t.Fatal("underlying writer not hijacked")
+	}
+	if findKV(log.args, "status") != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status 101, got %v", findKV(log.args, "status"))
+	}
+}
+
 func TestLogging_WarnOnClientError(t *testing.T) {
 	t.Parallel()
 	log := &mockLogger{}
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"runtime/debug"
+
+	"github.com/shuldan/framework/httpserver"
 )
 
 const errBody = `{"code":"internal","message":"internal error"}`
@@ -34,6 +37,11 @@ func handlePanic(
 		)
 	}
 
+	if httpserver.ProblemDetailsEnabled(w) {
+		httpserver.Error(w, httpserver.ErrInternal.WithCause(fmt.Errorf("panic: %v", rec)))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusInternalServerError)
 	_, _ = w.Write([]byte(errBody))
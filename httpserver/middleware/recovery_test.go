@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/shuldan/framework/httpserver"
 )
 
 func TestRecovery_NoPanic(t *testing.T) {
@@ -33,7 +35,7 @@ func TestRecovery_CatchesPanic(t *testing.T) {
 	if rr.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500, got %d", rr.Code)
 	}
-	if !strings.Contains(rr.Body.String(), "internal error") {
+	if rr.Body.String() != `{"code":"internal","message":"internal error"}` {
 		t.Fatalf("unexpected body: %s", rr.Body.String())
 	}
 }
@@ -68,3 +70,24 @@ func TestRecovery_ContentType(t *testing.T) {
 		t.Fatalf("expected application/json, got %q", ct)
 	}
 }
+
+func TestRecovery_ProblemDetails(t *testing.T) {
+	t.Parallel()
+	router := httpserver.NewRouter()
+	router.UseProblemDetails(httpserver.ProblemConfig{})
+	router.Use(RequestID(), Recovery(nil))
+	router.GET("/", func(_ http.ResponseWriter, _ *http.Request) {
+		panic("boom")
+	})
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "req-42")
+	router.ServeHTTP(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); ct != httpserver.ContentTypeProblem {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	if !strings.Contains(rr.Body.String(), `"instance":"req-42"`) {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+}
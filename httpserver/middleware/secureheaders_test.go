@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+func TestSecureHeaders_Strict(t *testing.T) {
+	t.Parallel()
+	handler := SecureHeaders(StrictSecureHeaders())(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	hsts := rr.Header().Get("Strict-Transport-Security")
+	if hsts != "max-age=63072000; includeSubDomains; preload" {
+		t.Fatalf("unexpected HSTS: %q", hsts)
+	}
+	expected := map[string]string{
+		"X-Frame-Options":              "DENY",
+		"Referrer-Policy":              "no-referrer",
+		"X-Content-Type-Options":       "nosniff",
+		"Cross-Origin-Opener-Policy":   "same-origin",
+		"Cross-Origin-Embedder-Policy": "require-corp",
+	}
+	for k, v := range expected {
+		if got := rr.Header().Get(k); got != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestSecureHeaders_NonceInContextAndPolicy(t *testing.T) {
+	t.Parallel()
+	var nonce string
+	handler := SecureHeaders(StrictSecureHeaders())(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			nonce = CSPNonce(r.Context())
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if nonce == "" {
+		t.Fatal("expected nonce in context")
+	}
+	csp := rr.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "'nonce-"+nonce+"'") {
+		t.Fatalf("expected nonce in CSP, got %q", csp)
+	}
+	if strings.Contains(csp, cspNoncePlaceholder) {
+		t.Fatalf("placeholder not replaced: %q", csp)
+	}
+}
+
+func TestSecureHeaders_NoncePerRequest(t *testing.T) {
+	t.Parallel()
+	handler := SecureHeaders(StrictSecureHeaders())(okHandler())
+	rr1 := httptest.NewRecorder()
+	rr2 := httptest.NewRecorder()
+	handler.ServeHTTP(rr1, httptest.NewRequest("GET", "/", nil))
+	handler.ServeHTTP(rr2, httptest.NewRequest("GET", "/", nil))
+	if rr1.Header().Get("Content-Security-Policy") == rr2.Header().Get("Content-Security-Policy") {
+		t.Fatal("expected distinct nonces")
+	}
+}
+
+func TestSecureHeaders_APIPreset(t *testing.T) {
+	t.Parallel()
+	handler := SecureHeaders(APISecureHeaders())(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if v := rr.Header().Get("Content-Security-Policy"); v != "default-src 'none'; frame-ancestors 'none'" {
+		t.Fatalf("unexpected CSP: %q", v)
+	}
+	if v := rr.Header().Get("Strict-Transport-Security"); v != "max-age=31536000; includeSubDomains" {
+		t.Fatalf("unexpected HSTS: %q", v)
+	}
+	if v := rr.Header().Get("Permissions-Policy"); v != "" {
+		t.Fatalf("expected no Permissions-Policy, got %q", v)
+	}
+}
+
+func TestSecureHeaders_ReportOnly(t *testing.T) {
+	t.Parallel()
+	handler := SecureHeaders(SecureHeadersConfig{
+		ContentSecurityPolicy: "default-src 'self'",
+		CSPReportOnly:         true,
+	})(okHandler())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get("Content-Security-Policy") != "" {
+		t.Fatal("expected no enforcing CSP")
+	}
+	if rr.Header().Get("Content-Security-Policy-Report-Only") != "default-src 'self'" {
+		t.Fatal("expected report-only CSP")
+	}
+}
+
+func TestSecureHeaders_InnerOverridesOuter(t *testing.T) {
+	t.Parallel()
+	inner := SecureHeaders(SecureHeadersConfig{FrameOptions: "SAMEORIGIN"})(okHandler())
+	handler := SecureHeaders(SecureHeadersConfig{
+		FrameOptions: "DENY",
+		HSTSMaxAge:   time.Hour,
+	})(inner)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if v := rr.Header().Get("X-Frame-Options"); v != "SAMEORIGIN" {
+		t.Fatalf("expected SAMEORIGIN, got %q", v)
+	}
+	if v := rr.Header().Get("Strict-Transport-Security"); v != "max-age=3600" {
+		t.Fatalf("expected outer HSTS kept, got %q", v)
+	}
+}
+
+func TestSecureHeaders_GroupRemovesAndReplacesRootHeaders(t *testing.T) {
+	t.Parallel()
+	router := httpserver.NewRouter()
+	router.Use(SecureHeaders(APISecureHeaders()))
+	widgets := router.Group("/widgets", SecureHeaders(SecureHeadersConfig{
+		ContentSecurityPolicy: "default-src 'self'; frame-ancestors https://partner.com",
+		Remove:                []string{"X-Frame-Options"},
+	}))
+	widgets.GET("/chart", okHandler().ServeHTTP)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/widgets/chart", nil))
+	h := rr.Header()
+	if v := h.Get("Content-Security-Policy"); v != "default-src 'self'; frame-ancestors https://partner.com" {
+		t.Fatalf("expected the group CSP to replace the root one, got %q", v)
+	}
+	if _, ok := h["X-Frame-Options"]; ok {
+		t.Fatalf("expected X-Frame-Options removed, got %q", h.Get("X-Frame-Options"))
+	}
+	if h.Get("Strict-Transport-Security") == "" {
+		t.Fatal("expected unrelated root headers kept")
+	}
+}
+
+func TestCSPNonce_Empty(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("GET", "/", nil)
+	if CSPNonce(r.Context()) != "" {
+		t.Fatal("expected empty nonce")
+	}
+}
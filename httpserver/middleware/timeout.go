@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+type TimeoutConfig struct {
+	Timeout time.Duration
+	Status  int // 503 by default, 504 for gateway-style routes
+	// Log reports panics raised after the timeout response was sent;
+	// with nil they are recovered and dropped.
+	Log func(msg string, args ...any)
+}
+
+func Timeout(cfg TimeoutConfig) func(http.Handler) http.Handler {
+	status := cfg.Status
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), cfg.Timeout)
+			defer cancel()
+
+			tw := newTimeoutWriter(w)
+			done := make(chan struct{})
+			panicCh := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					p := recover()
+					if !tw.finish(p != nil) {
+						latePanic(cfg.Log, p)
+						return
+					}
+
+					if p != nil {
+						panicCh <- p
+						return
+					}
+
+					close(done)
+				}()
+
+				next.ServeHTTP(tw, r.WithContext(ctx))
+			}()
+
+			select {
+			case p := <-panicCh:
+				panic(p)
+			case <-done:
+				tw.flush()
+			case <-ctx.Done():
+				if tw.expire(ctx.Err(), status) {
+					return
+				}
+
+				// The handler finished while the deadline fired.
+				select {
+				case p := <-panicCh:
+					panic(p)
+				case <-done:
+					tw.flush()
+				}
+			}
+		})
+	}
+}
+
+// latePanic surfaces a panic the request can no longer answer for.
+func latePanic(log func(msg string, args ...any), p any) {
+	if p == nil || log == nil {
+		return
+	}
+
+	log("panic after timeout",
+		"error", p,
+		"stack", string(debug.Stack()),
+	)
+}
+
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	buf         bytes.Buffer
+	mu          sync.Mutex
+	status      int
+	wroteHeader bool
+	timedOut    bool
+	finished    bool
+	panicked    bool
+	committed   bool
+}
+
+func newTimeoutWriter(w http.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		w:      w,
+		header: w.Header().Clone(),
+		status: http.StatusOK,
+	}
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.status = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.wroteHeader = true
+
+	if tw.committed {
+		return tw.w.Write(b)
+	}
+
+	return tw.buf.Write(b)
+}
+
+// Flush commits the buffered response for streaming handlers (SSE);
+// after that the deadline can only cut the stream, not replace it.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+
+	tw.wroteHeader = true
+	tw.commit()
+	_ = http.NewResponseController(tw.w).Flush()
+}
+
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.commit()
+}
+
+func (tw *timeoutWriter) commit() {
+	if tw.committed {
+		return
+	}
+
+	tw.committed = true
+
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+
+	tw.w.WriteHeader(tw.status)
+	_, _ = tw.w.Write(tw.buf.Bytes())
+	tw.buf.Reset()
+}
+
+// finish marks the handler as returned; false means the timeout
+// response has already been sent.
+func (tw *timeoutWriter) finish(panicked bool) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return false
+	}
+
+	tw.finished = true
+	tw.panicked = panicked
+
+	return true
+}
+
+// expire answers with the timeout error unless the handler has
+// already returned with a response or a panic of its own.
+func (tw *timeoutWriter) expire(cause error, status int) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.finished && (tw.wroteHeader || tw.panicked) {
+		return false
+	}
+
+	tw.timedOut = true
+
+	if !tw.committed && errors.Is(cause, context.DeadlineExceeded) {
+		httpserver.ErrorStatus(tw.w, status, ErrTimeout)
+	}
+
+	return true
+}
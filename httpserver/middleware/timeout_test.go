@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+func TestTimeout_HandlerFinishesInTime(t *testing.T) {
+	t.Parallel()
+	handler := Timeout(TimeoutConfig{Timeout: time.Second})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("X-Handler", "yes")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("done"))
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rr.Code)
+	}
+	if rr.Body.String() != "done" {
+		t.Fatalf("expected 'done', got %q", rr.Body.String())
+	}
+	if rr.Header().Get("X-Handler") != "yes" {
+		t.Error("expected handler header to be copied")
+	}
+}
+
+func TestTimeout_WritesErrorOnDeadline(t *testing.T) {
+	t.Parallel()
+	writeErr := make(chan error, 1)
+	handler := Timeout(TimeoutConfig{Timeout: 10 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			time.Sleep(5 * time.Millisecond)
+			_, err := w.Write([]byte("late"))
+			writeErr <- err
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+	var body domainerrors.PublicError
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	if body.Code != "REQUEST_TIMEOUT" {
+		t.Fatalf("expected REQUEST_TIMEOUT, got %q", body.Code)
+	}
+	if err := <-writeErr; err != http.ErrHandlerTimeout {
+		t.Fatalf("expected ErrHandlerTimeout, got %v", err)
+	}
+}
+
+func TestTimeout_CustomStatus(t *testing.T) {
+	t.Parallel()
+	handler := Timeout(TimeoutConfig{
+		Timeout: 5 * time.Millisecond,
+		Status:  http.StatusGatewayTimeout,
+	})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", rr.Code)
+	}
+}
+
+func TestTimeout_PropagatesPanic(t *testing.T) {
+	t.Parallel()
+	handler := Recovery(nil)(Timeout(TimeoutConfig{Timeout: time.Second})(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			panic("boom")
+		}),
+	))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+}
+
+func TestTimeout_ClientCancelWritesNothing(t *testing.T) {
+	t.Parallel()
+	handler := Timeout(TimeoutConfig{Timeout: time.Second})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}),
+	)
+	req := httptest.NewRequest("GET", "/", nil)
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req.WithContext(ctx))
+	if rr.Body.Len() != 0 {
+		t.Fatalf("expected empty body, got %q", rr.Body.String())
+	}
+}
+
+func TestTimeout_LogsLatePanic(t *testing.T) {
+	t.Parallel()
+	logged := make(chan any, 1)
+	handler := Timeout(TimeoutConfig{
+		Timeout: 5 * time.Millisecond,
+		Log:     func(_ string, args ...any) { logged <- args[1] },
+	})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			time.Sleep(5 * time.Millisecond)
+			panic("late")
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+	select {
+	case p := <-logged:
+		if p != "late" {
+			t.Fatalf("unexpected panic value %v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected late panic to be logged")
+	}
+}
+
+func TestTimeout_DropsLatePanicWithoutLog(t *testing.T) {
+	t.Parallel()
+	returned := make(chan struct{})
+	handler := Timeout(TimeoutConfig{Timeout: 5 * time.Millisecond})(
+		http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			defer close(returned)
+			<-r.Context().Done()
+			time.Sleep(5 * time.Millisecond)
+			panic("late")
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	<-returned
+	time.Sleep(5 * time.Millisecond)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+}
+
+func TestTimeout_FlushStreamsThroughResponseController(t *testing.T) {
+	t.Parallel()
+	handler := Timeout(TimeoutConfig{Timeout: time.Second})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: 1\n\n"))
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("flush: %v", err)
+			}
+			_, _ = w.Write([]byte("data: 2\n\n"))
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if !rr.Flushed {
+		t.Fatal("expected the underlying writer to be flushed")
+	}
+	assertTimeoutBody(t, rr, "data: 1\n\ndata: 2\n\n")
+	if rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestTimeout_DeadlineCutsCommittedStream(t *testing.T) {
+	t.Parallel()
+	handler := Timeout(TimeoutConfig{Timeout: 5 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("data: 1\n\n"))
+			_ = http.NewResponseController(w).Flush()
+			<-r.Context().Done()
+		}),
+	)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	assertTimeoutBody(t, rr, "data: 1\n\n")
+}
+
+func assertTimeoutBody(t *testing.T, rr *httptest.ResponseRecorder, want string) {
+	t.Helper()
+	if rr.Body.String() != want {
+		t.Fatalf("expected body %q, got %q", want, rr.Body.String())
+	}
+}
+
+func TestTimeoutWriter_FinishedHandlerWinsOverDeadline(t *testing.T) {
+	t.Parallel()
+	rr := httptest.NewRecorder()
+	tw := newTimeoutWriter(rr)
+	_, _ = tw.Write([]byte("done"))
+	if !tw.finish(false) || tw.expire(context.DeadlineExceeded, http.StatusServiceUnavailable) {
+		t.Fatal("expected finished handler to suppress the timeout response")
+	}
+	if rr.Body.Len() != 0 {
+		t.Fatalf("expected no timeout body, got %q", rr.Body.String())
+	}
+
+	tw = newTimeoutWriter(httptest.NewRecorder())
+	if !tw.expire(context.DeadlineExceeded, http.StatusServiceUnavailable) || tw.finish(false) {
+		t.Fatal("expected handler returning after the timeout to be late")
+	}
+}
@@ -0,0 +1,168 @@
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Respond encodes data with the encoder that best matches the request
+// Accept header (q-values honoured, JSON when Accept is absent) among
+// those able to encode data, and answers 406 when none is acceptable.
+func Respond(w http.ResponseWriter, r *http.Request, status int, data any) {
+	w.Header().Add("Vary", "Accept")
+
+	encs := encodersFor(data)
+
+	enc := negotiate(r.Header.Get("Accept"), encs)
+	if enc == nil {
+		Error(w, ErrNotAcceptable.WithDetail("available", joinMediaTypes(encs)))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, data); err != nil {
+		Error(w, ErrInternal.WithCause(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// encodersFor drops registered encoders that cannot encode data.
+func encodersFor(data any) []Encoder {
+	encs := registeredEncoders()
+	usable := encs[:0]
+
+	for _, enc := range encs {
+		if ve, ok := enc.(ValueEncoder); ok && !ve.CanEncode(data) {
+			continue
+		}
+
+		usable = append(usable, enc)
+	}
+
+	return usable
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+func negotiate(accept string, encs []Encoder) Encoder {
+	if strings.TrimSpace(accept) == "" {
+		if len(encs) == 0 {
+			return nil
+		}
+
+		return encs[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	var (
+		best     Encoder
+		bestRank = len(ranges)
+	)
+
+	for _, enc := range encs {
+		rank := decisiveRange(ranges, enc.ContentType())
+		if rank < 0 || ranges[rank].q <= 0 {
+			continue
+		}
+
+		if rank < bestRank {
+			best, bestRank = enc, rank
+		}
+	}
+
+	return best
+}
+
+// decisiveRange returns the index of the most specific range matching
+// mediaType (RFC 9110, section 12.5.1), so "application/xml;q=0, */*"
+// refuses XML; -1 when no range matches.
+func decisiveRange(ranges []acceptRange, mediaType string) int {
+	rank, best := -1, -1
+
+	for i, ar := range ranges {
+		if spec := matchSpecificity(ar.mediaType, mediaType); spec > best {
+			rank, best = i, spec
+		}
+	}
+
+	return rank
+}
+
+// parseAccept returns the ranges ordered by q-value, then by
+// specificity; q=0 ranges are kept because they exclude types.
+func parseAccept(accept string) []acceptRange {
+	parts := strings.Split(accept, ",")
+	ranges := make([]acceptRange, 0, len(parts))
+
+	for _, part := range parts {
+		fields := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mt == "" {
+			continue
+		}
+
+		q := 1.0
+
+		for _, param := range fields[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "q") {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mt, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+
+		return strings.Count(ranges[i].mediaType, "*") <
+			strings.Count(ranges[j].mediaType, "*")
+	})
+
+	return ranges
+}
+
+// matchSpecificity ranks how closely pattern names mediaType: 3 exact,
+// 2 structured syntax suffix, 1 type/*, 0 */*, -1 no match.
+func matchSpecificity(pattern, mediaType string) int {
+	switch {
+	case pattern == mediaType:
+		return 3
+	case pattern == "*/*":
+		return 0
+	}
+
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		if strings.HasPrefix(mediaType, prefix+"/") {
+			return 1
+		}
+
+		return -1
+	}
+
+	// A structured syntax suffix (RFC 6839) is served by the base codec:
+	// application/vnd.acme.v2+json by application/json.
+	typ, subtype, _ := strings.Cut(pattern, "/")
+	if i := strings.LastIndexByte(subtype, '+'); i >= 0 && typ+"/"+subtype[i+1:] == mediaType {
+		return 2
+	}
+
+	return -1
+}
@@ -0,0 +1,186 @@
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+type negotiationItem struct {
+	ID      int       `json:"id" xml:"id" csv:"id"`
+	Name    string    `json:"name" xml:"name" csv:"name"`
+	Created time.Time `json:"created" xml:"created" csv:"created_at"`
+	Secret  string    `json:"-" xml:"-" csv:"-"`
+}
+
+func respondWithAccept(accept string, data any) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	Respond(rr, req, http.StatusOK, data)
+
+	return rr
+}
+
+func TestRespond_DefaultsToJSON(t *testing.T) {
+	t.Parallel()
+	rr := respondWithAccept("", map[string]int{"a": 1})
+	assertStatus(t, http.StatusOK, rr)
+	assertHeader(t, "Content-Type", "application/json", rr)
+	assertHeader(t, "Vary", "Accept", rr)
+}
+
+func TestRespond_QValues(t *testing.T) {
+	t.Parallel()
+	item := negotiationItem{ID: 1, Name: "x"}
+	rr := respondWithAccept("application/json;q=0.5, application/xml", item)
+	assertHeader(t, "Content-Type", "application/xml", rr)
+
+	if !strings.Contains(rr.Body.String(), "<name>x</name>") {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestRespond_Wildcards(t *testing.T) {
+	t.Parallel()
+	rr := respondWithAccept("text/*", []negotiationItem{{ID: 1}})
+	assertHeader(t, "Content-Type", "text/csv", rr)
+
+	rr = respondWithAccept("image/png, */*;q=0.1", map[string]int{})
+	assertHeader(t, "Content-Type", "application/json", rr)
+}
+
+func TestRespond_NotAcceptable(t *testing.T) {
+	t.Parallel()
+	rr := respondWithAccept("image/png, application/json;q=0", map[string]int{})
+	assertStatus(t, http.StatusNotAcceptable, rr)
+
+	if !strings.Contains(rr.Body.String(), "NOT_ACCEPTABLE") {
+		t.Fatalf("unexpected body: %s", rr.Body.String())
+	}
+}
+
+func TestRespond_MostSpecificRangeDecides(t *testing.T) {
+	t.Parallel()
+	item := negotiationItem{ID: 1}
+	rr := respondWithAccept("application/xml;q=0, */*", item)
+	assertHeader(t, "Content-Type", "application/json", rr)
+
+	rr = respondWithAccept("application/json;q=0.5, */*", item)
+	assertHeader(t, "Content-Type", "application/xml", rr)
+
+	rr = respondWithAccept("application/*;q=0, text/csv;q=0.5", item)
+	assertStatus(t, http.StatusNotAcceptable, rr)
+}
+
+func TestRespond_SkipsEncodersThatCannotEncode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		accept string
+		data   any
+	}{
+		{"text/csv", map[string]int{}},
+		{"text/*", negotiationItem{ID: 1}},
+		{"application/xml", map[string]int{"a": 1}},
+		{"application/xml", struct{ M map[string]int }{}},
+	}
+	for _, tt := range tests {
+		rr := respondWithAccept(tt.accept, tt.data)
+		assertStatus(t, http.StatusNotAcceptable, rr)
+		assertContains(t, rr.Body.String(), "NOT_ACCEPTABLE")
+	}
+
+	rr := respondWithAccept("application/xml, */*;q=0.5", map[string]int{"a": 1})
+	assertStatus(t, http.StatusOK, rr)
+	assertHeader(t, "Content-Type", "application/json", rr)
+}
+
+func TestRespond_EncodeError(t *testing.T) {
+	t.Parallel()
+	rr := respondWithAccept("", map[string]any{"c": make(chan int)})
+	assertStatus(t, http.StatusInternalServerError, rr)
+	assertContains(t, rr.Body.String(), "INTERNAL")
+}
+
+func TestCSVEncoder(t *testing.T) {
+	t.Parallel()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	items := []*negotiationItem{{ID: 1, Name: "a,b", Created: created, Secret: "s"}, nil}
+
+	var sb strings.Builder
+	if err := (CSVEncoder{}).Encode(&sb, items); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,name,created_at\n1,\"a,b\",2024-01-02T03:04:05Z\n,,\n"
+	if sb.String() != want {
+		t.Fatalf("got %q, want %q", sb.String(), want)
+	}
+}
+
+func TestCSVEncoder_RejectsNonStructSlice(t *testing.T) {
+	t.Parallel()
+
+	if err := (CSVEncoder{}).Encode(io.Discard, []int{1}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseAccept_OrdersBySpecificity(t *testing.T) {
+	t.Parallel()
+	ranges := parseAccept("*/*, text/*, text/csv;q=1")
+
+	if ranges[0].mediaType != "text/csv" || ranges[2].mediaType != "*/*" {
+		t.Fatalf("unexpected order: %+v", ranges)
+	}
+}
+
+func TestDecoders_JSONOnlyByDefault(t *testing.T) {
+	t.Parallel()
+	reg := newCodecRegistry()
+	if _, ok := reg.decoderFor("application/xml"); ok {
+		t.Fatal("expected no XML decoder by default")
+	}
+	reg.registerDecoder(XMLCodec{}, "text/xml")
+	if _, ok := reg.decoderFor("text/xml; charset=utf-8"); !ok {
+		t.Fatal("expected alias to be registered")
+	}
+}
+
+func TestBind_XML(t *testing.T) {
+	t.Parallel()
+	RegisterDecoder(XMLCodec{}, "text/xml")
+	req := httptest.NewRequest(http.MethodPost, "/",
+		strings.NewReader("<item><id>7</id><name>x</name></item>"))
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	var got negotiationItem
+	if err := Bind(req, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != 7 || got.Name != "x" {
+		t.Fatalf("unexpected value: %+v", got)
+	}
+}
+
+func TestBind_XMLInvalid(t *testing.T) {
+	t.Parallel()
+	RegisterDecoder(XMLCodec{}, "text/xml")
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<item><id>"))
+	req.Header.Set("Content-Type", "text/xml")
+
+	var got negotiationItem
+	if err := Bind(req, &got); !domainerrors.Is(err, ErrInvalidBody) {
+		t.Fatalf("expected ErrInvalidBody, got %v", err)
+	}
+}
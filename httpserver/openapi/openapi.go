@@ -0,0 +1,349 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+const DefaultPath = "/openapi.json"
+
+type Config struct {
+	Info    Info
+	Servers []Server
+	// ProblemDetails documents errors as application/problem+json; set
+	// it together with Router.UseProblemDetails.
+	ProblemDetails bool
+	// Path is where Mount serves the document (default /openapi.json).
+	Path string
+}
+
+// Generate builds an OpenAPI 3.1 document from registered routes.
+// Routes without metadata are still listed, with path parameters
+// taken from the pattern.
+func Generate(routes []httpserver.Route, cfg Config) *Document {
+	g := &generator{cfg: cfg, schemas: newSchemaRegistry()}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    cfg.Info,
+		Servers: cfg.Servers,
+		Paths:   make(map[string]PathItem),
+	}
+
+	for _, route := range routes {
+		if route.Meta.Hidden || route.Method == "" {
+			continue
+		}
+
+		path := openAPIPath(route.Pattern)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+
+		doc.Paths[path][strings.ToLower(route.Method)] = g.operation(route, path)
+	}
+
+	g.addErrorSchema()
+
+	if len(g.schemas.schemas) > 0 {
+		doc.Components.Schemas = g.schemas.schemas
+	}
+
+	return doc
+}
+
+// Handler serves the document generated from router's routes on the
+// first request, once all routes have been registered.
+func Handler(router *httpserver.Router, cfg Config) http.HandlerFunc {
+	doc := sync.OnceValue(func() *Document {
+		return Generate(router.Routes(), cfg)
+	})
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		httpserver.OK(w, doc())
+	}
+}
+
+// Mount registers Handler at cfg.Path and hides it from the document.
+func Mount(router *httpserver.Router, cfg Config) {
+	path := cfg.Path
+	if path == "" {
+		path = DefaultPath
+	}
+
+	router.GET(path, Handler(router, cfg), httpserver.WithHidden())
+}
+
+type generator struct {
+	cfg       Config
+	schemas   *schemaRegistry
+	useErrors bool
+}
+
+func (g *generator) operation(route httpserver.Route, path string) *Operation {
+	meta := route.Meta
+
+	op := &Operation{
+		OperationID: meta.OperationID,
+		Summary:     meta.Summary,
+		Description: meta.Description,
+		Tags:        meta.Tags,
+		Deprecated:  meta.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+
+	errs := meta.Errors
+
+	if meta.Request != nil {
+		op.Parameters = g.parameters(meta.Request)
+		op.RequestBody = g.requestBody(meta.Request, route.Method)
+
+		var bindErrs httpserver.RouteMeta
+		httpserver.WithErrors(httpserver.ErrValidation)(&bindErrs)
+		errs = append(slices.Clone(errs), bindErrs.Errors...)
+	}
+
+	op.Parameters = addPathParameters(op.Parameters, path)
+
+	for _, resp := range meta.Responses {
+		r := &Response{Description: statusDescription(resp.Status)}
+		if resp.Type != nil {
+			r.Content = map[string]MediaType{
+				"application/json": {Schema: g.schemas.schemaFor(resp.Type)},
+			}
+		}
+
+		op.Responses[strconv.Itoa(resp.Status)] = r
+	}
+
+	if len(meta.Responses) == 0 {
+		op.Responses["200"] = &Response{Description: statusDescription(http.StatusOK)}
+	}
+
+	g.addErrorResponses(op, errs)
+
+	return op
+}
+
+func (g *generator) parameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []Parameter
+
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+
+		source, ok := sourceTag(sf)
+		if !ok || source == "form" {
+			continue
+		}
+
+		schema := g.parameterSchema(sf.Type)
+		required := applyRules(schema, sf)
+
+		params = append(params, Parameter{
+			Name:     sf.Tag.Get(source),
+			In:       source,
+			Required: required || source == "path",
+			Schema:   schema,
+		})
+	}
+
+	return params
+}
+
+func (g *generator) parameterSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return &Schema{Type: "string", Format: "duration"}
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem() == durationType {
+		return &Schema{Type: "array", Items: g.parameterSchema(t.Elem())}
+	}
+
+	return g.schemas.schemaFor(t)
+}
+
+func (g *generator) requestBody(t reflect.Type, method string) *RequestBody {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: g.schemas.schemaFor(t)}},
+		}
+	}
+
+	content := make(map[string]MediaType)
+
+	if form := g.schemas.objectSchema(t, true); len(form.Properties) > 0 {
+		content["application/x-www-form-urlencoded"] = MediaType{Schema: form}
+		content["multipart/form-data"] = MediaType{Schema: form}
+	}
+
+	if hasBody(method) && len(g.schemas.objectSchema(t, false).Properties) > 0 {
+		content["application/json"] = MediaType{Schema: g.schemas.schemaFor(t)}
+	}
+
+	if len(content) == 0 {
+		return nil
+	}
+
+	return &RequestBody{Required: true, Content: content}
+}
+
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// addErrorResponses groups declared errors by status, listing the
+// codes a client can expect under each.
+func (g *generator) addErrorResponses(op *Operation, errs []httpserver.RouteError) {
+	if len(errs) == 0 {
+		return
+	}
+
+	g.useErrors = true
+
+	codes := make(map[int][]string)
+	for _, e := range errs {
+		if !slices.Contains(codes[e.Status], e.Code) {
+			codes[e.Status] = append(codes[e.Status], e.Code)
+		}
+	}
+
+	mediaType, schema := g.errorMedia()
+
+	for status, list := range codes {
+		sort.Strings(list)
+
+		op.Responses[strconv.Itoa(status)] = &Response{
+			Description: statusDescription(status) + ": " + strings.Join(list, ", "),
+			Content: map[string]MediaType{
+				mediaType: {Schema: &Schema{Ref: schemaRefPrefix + schema}},
+			},
+		}
+	}
+}
+
+func (g *generator) errorMedia() (string, string) {
+	if g.cfg.ProblemDetails {
+		return httpserver.ContentTypeProblem, "Problem"
+	}
+
+	return "application/json", "Error"
+}
+
+func (g *generator) addErrorSchema() {
+	if !g.useErrors {
+		return
+	}
+
+	str := func() *Schema { return &Schema{Type: "string"} }
+
+	if g.cfg.ProblemDetails {
+		g.schemas.schemas["Problem"] = &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"type":     {Type: "string", Format: "uri-reference"},
+				"title":    str(),
+				"status":   {Type: "integer"},
+				"detail":   str(),
+				"instance": str(),
+				"code":     str(),
+			},
+			Required: []string{"type", "title", "status", "code"},
+		}
+
+		return
+	}
+
+	g.schemas.schemas["Error"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    str(),
+			"message": str(),
+			"details": {Type: "object"},
+		},
+		Required: []string{"code", "message"},
+	}
+}
+
+func addPathParameters(params []Parameter, path string) []Parameter {
+	for _, segment := range strings.Split(path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+
+		name = strings.TrimSuffix(name, "}")
+
+		declared := false
+		for _, p := range params {
+			if p.In == "path" && p.Name == name {
+				declared = true
+				break
+			}
+		}
+
+		if !declared {
+			params = append(params, Parameter{
+				Name: name, In: "path", Required: true,
+				Schema: &Schema{Type: "string"},
+			})
+		}
+	}
+
+	return params
+}
+
+// openAPIPath converts a ServeMux pattern to an OpenAPI path template:
+// the host is dropped, {name...} becomes {name} and {$} is removed.
+func openAPIPath(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+
+	pattern = strings.ReplaceAll(pattern, "...}", "}")
+	pattern = strings.TrimSuffix(pattern, "{$}")
+
+	if pattern == "" {
+		return "/"
+	}
+
+	return pattern
+}
+
+func statusDescription(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+
+	return "Status " + strconv.Itoa(status)
+}
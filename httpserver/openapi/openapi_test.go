@@ -0,0 +1,221 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+var errOrderNotFound = domainerrors.NewCode("ORDER_NOT_FOUND").
+	Kind(domainerrors.NotFound).
+	New("order not found")
+
+type createOrderRequest struct {
+	TenantID string   `path:"tenant"`
+	DryRun   bool     `query:"dry_run"`
+	Trace    string   `header:"X-Trace" validate:"required"`
+	Items    []string `json:"items" validate:"required,min=1"`
+	Note     string   `json:"note,omitempty" validate:"max=200"`
+	Status   string   `json:"status" validate:"oneof=new paid"`
+	Email    string   `json:"email" validate:"email"`
+	Internal string   `json:"-"`
+}
+
+type order struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Parent    *order    `json:"parent,omitempty"`
+	Lines     []line    `json:"lines"`
+}
+
+type line struct {
+	Qty   uint               `json:"qty"`
+	Attrs map[string]float64 `json:"attrs"`
+}
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func testRouter() *httpserver.Router {
+	router := httpserver.NewRouter()
+	router.POST("/tenants/{tenant}/orders", noop,
+		httpserver.WithOperationID("createOrder"),
+		httpserver.WithSummary("Create order"),
+		httpserver.WithTags("orders"),
+		httpserver.WithRequest[createOrderRequest](),
+		httpserver.WithResponse[order](http.StatusCreated),
+		httpserver.WithErrors(errOrderNotFound),
+	)
+	router.GET("/files/{path...}", noop)
+	router.DELETE("/orders/{id}", noop,
+		httpserver.WithEmptyResponse(http.StatusNoContent),
+		httpserver.WithDeprecated(),
+	)
+	router.GET("/internal", noop, httpserver.WithHidden())
+
+	return router
+}
+
+func TestGenerate_Operation(t *testing.T) {
+	t.Parallel()
+	doc := Generate(testRouter().Routes(), Config{Info: Info{Title: "Orders", Version: "1.0"}})
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Orders" {
+		t.Fatalf("unexpected header: %+v", doc)
+	}
+
+	op := doc.Paths["/tenants/{tenant}/orders"]["post"]
+	if op == nil || op.OperationID != "createOrder" || op.Tags[0] != "orders" {
+		t.Fatalf("unexpected operation: %+v", op)
+	}
+
+	want := []Parameter{
+		{Name: "tenant", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "dry_run", In: "query", Schema: &Schema{Type: "boolean"}},
+		{Name: "X-Trace", In: "header", Required: true, Schema: &Schema{Type: "string"}},
+	}
+	if !reflect.DeepEqual(op.Parameters, want) {
+		t.Fatalf("parameters:\n got %+v\nwant %+v", op.Parameters, want)
+	}
+
+	if got := op.Responses["201"].Content["application/json"].Schema.Ref; got != "#/components/schemas/order" {
+		t.Fatalf("unexpected 201 schema: %q", got)
+	}
+
+	if op.Responses["404"].Description != "Not Found: ORDER_NOT_FOUND" {
+		t.Fatalf("unexpected 404: %+v", op.Responses["404"])
+	}
+
+	if op.Responses["400"].Description != "Bad Request: VALIDATION_FAILED" {
+		t.Fatalf("unexpected 400: %+v", op.Responses["400"])
+	}
+}
+
+func TestGenerate_RequestBodySchema(t *testing.T) {
+	t.Parallel()
+	doc := Generate(testRouter().Routes(), Config{})
+	body := doc.Components.Schemas["createOrderRequest"]
+
+	if body == nil {
+		t.Fatal("request schema not registered")
+	}
+
+	if len(body.Properties) != 4 {
+		t.Fatalf("expected only JSON members, got %v", body.Properties)
+	}
+
+	if !reflect.DeepEqual(body.Required, []string{"items"}) {
+		t.Fatalf("unexpected required: %v", body.Required)
+	}
+
+	if *body.Properties["items"].MinItems != 1 || *body.Properties["note"].MaxLength != 200 {
+		t.Fatal("validate bounds not mapped")
+	}
+
+	if !reflect.DeepEqual(body.Properties["status"].Enum, []any{"new", "paid"}) {
+		t.Fatalf("unexpected enum: %v", body.Properties["status"].Enum)
+	}
+
+	if body.Properties["email"].Format != "email" {
+		t.Fatal("email format not mapped")
+	}
+}
+
+func TestGenerate_TypeSchemas(t *testing.T) {
+	t.Parallel()
+	doc := Generate(testRouter().Routes(), Config{})
+	o := doc.Components.Schemas["order"]
+
+	if o.Properties["created_at"].Format != "date-time" {
+		t.Fatal("time.Time must be date-time")
+	}
+
+	if o.Properties["parent"].Ref != "#/components/schemas/order" {
+		t.Fatal("recursive type must use $ref")
+	}
+
+	l := doc.Components.Schemas["line"]
+	if l.Properties["attrs"].AdditionalProperties.Type != "number" {
+		t.Fatal("map values not described")
+	}
+
+	if doc.Components.Schemas["Error"] == nil {
+		t.Fatal("error schema missing")
+	}
+}
+
+func TestGenerate_PathsAndDefaults(t *testing.T) {
+	t.Parallel()
+	doc := Generate(testRouter().Routes(), Config{})
+
+	files := doc.Paths["/files/{path}"]["get"]
+	if files == nil || files.Parameters[0].Name != "path" || files.Responses["200"] == nil {
+		t.Fatalf("unexpected files operation: %+v", files)
+	}
+
+	del := doc.Paths["/orders/{id}"]["delete"]
+	if !del.Deprecated || del.Responses["204"].Content != nil {
+		t.Fatalf("unexpected delete operation: %+v", del)
+	}
+
+	if _, ok := doc.Paths["/internal"]; ok {
+		t.Fatal("hidden route documented")
+	}
+}
+
+func TestGenerate_ProblemDetails(t *testing.T) {
+	t.Parallel()
+	doc := Generate(testRouter().Routes(), Config{ProblemDetails: true})
+	resp := doc.Paths["/tenants/{tenant}/orders"]["post"].Responses["404"]
+
+	if resp.Content[httpserver.ContentTypeProblem].Schema.Ref != "#/components/schemas/Problem" {
+		t.Fatalf("unexpected error media: %+v", resp.Content)
+	}
+}
+
+func TestMount(t *testing.T) {
+	t.Parallel()
+	router := testRouter()
+	Mount(router, Config{Info: Info{Title: "Orders", Version: "1.0"}})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, DefaultPath, nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Paths[DefaultPath]; ok {
+		t.Fatal("spec endpoint must be hidden")
+	}
+
+	if len(doc.Paths) != 3 {
+		t.Fatalf("unexpected paths: %v", doc.Paths)
+	}
+}
+
+func TestGenerate_TypedHandler(t *testing.T) {
+	t.Parallel()
+	router := httpserver.NewRouter()
+	router.Handle("POST", "/tenants/{tenant}/orders", httpserver.Handle(
+		func(context.Context, createOrderRequest) (order, error) { return order{}, nil },
+		httpserver.WithStatus(http.StatusCreated),
+	))
+
+	op := Generate(router.Routes(), Config{}).Paths["/tenants/{tenant}/orders"]["post"]
+	if op.RequestBody == nil || op.Responses["201"] == nil || op.Responses["400"] == nil {
+		t.Fatalf("typed handler not documented: %+v", op)
+	}
+}
@@ -0,0 +1,280 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// sourceTags mirror httpserver binding sources; tagged fields are
+// documented as parameters or form fields, not as JSON body members.
+var sourceTags = []string{"path", "query", "header", "cookie", "form"}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	durationType      = reflect.TypeFor[time.Duration]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (g *schemaRegistry) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() != reflect.Struct &&
+		reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: ptr(0.0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structRef registers named structs as components and inlines
+// anonymous ones. The name is reserved before the fields are walked so
+// recursive types terminate.
+func (g *schemaRegistry) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return g.objectSchema(t, false)
+	}
+
+	if name, ok := g.names[t]; ok {
+		return &Schema{Ref: schemaRefPrefix + name}
+	}
+
+	name := g.uniqueName(t)
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.objectSchema(t, false)
+
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+func (g *schemaRegistry) uniqueName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+
+	if _, taken := g.schemas[name]; !taken {
+		return name
+	}
+
+	pkg := t.PkgPath()
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	candidate := pkg + "." + name
+
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[candidate]; !taken {
+			return candidate
+		}
+
+		candidate = pkg + "." + name + strconv.Itoa(i)
+	}
+}
+
+// objectSchema describes the JSON members of a struct. With form set it
+// describes the form-tagged fields instead.
+func (g *schemaRegistry) objectSchema(t reflect.Type, form bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t, form)
+
+	return s
+}
+
+func (g *schemaRegistry) addFields(s *Schema, t reflect.Type, form bool) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && sf.Tag.Get("json") == "" {
+			g.addFields(s, sf.Type, form)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, ok := memberName(sf, form)
+		if !ok {
+			continue
+		}
+
+		prop := g.schemaFor(sf.Type)
+		if applyRules(prop, sf) {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties[name] = prop
+	}
+}
+
+func memberName(sf reflect.StructField, form bool) (string, bool) {
+	if form {
+		name, ok := sf.Tag.Lookup("form")
+		return name, ok
+	}
+
+	if _, ok := sourceTag(sf); ok {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+
+	if name == "" {
+		name = sf.Name
+	}
+
+	return name, true
+}
+
+func sourceTag(sf reflect.StructField) (string, bool) {
+	for _, source := range sourceTags {
+		if _, ok := sf.Tag.Lookup(source); ok {
+			return source, true
+		}
+	}
+
+	return "", false
+}
+
+// applyRules maps `validate` rules onto schema keywords and reports
+// whether the field is required.
+func applyRules(s *Schema, sf reflect.StructField) bool {
+	tag, ok := sf.Tag.Lookup("validate")
+	if !ok {
+		return false
+	}
+
+	t := sf.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	kind := t.Kind()
+
+	rules := strings.Split(tag, ",")
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "min", "max", "len":
+			applyBound(s, kind, name, arg)
+		case "oneof":
+			for _, v := range strings.Fields(arg) {
+				s.Enum = append(s.Enum, enumValue(kind, v))
+			}
+		case "email", "uuid":
+			s.Format = name
+		}
+	}
+
+	return slices.Contains(rules, "required")
+}
+
+func applyBound(s *Schema, kind reflect.Kind, name, arg string) {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return
+	}
+
+	n := int(limit)
+
+	switch kind {
+	case reflect.String:
+		setBounds(name, &s.MinLength, &s.MaxLength, n)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		setBounds(name, &s.MinItems, &s.MaxItems, n)
+	default:
+		if name != "max" {
+			s.Minimum = ptr(limit)
+		}
+
+		if name != "min" {
+			s.Maximum = ptr(limit)
+		}
+	}
+}
+
+func setBounds(name string, lower, upper **int, n int) {
+	if name != "max" {
+		*lower = ptr(n)
+	}
+
+	if name != "min" {
+		*upper = ptr(n)
+	}
+}
+
+func enumValue(kind reflect.Kind, v string) any {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+
+	return v
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,248 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// cursorToken is the signed payload behind ?cursor=. It is bound to the
+// sort order and the filters so a cursor cannot be replayed against
+// different ones.
+type cursorToken struct {
+	Sort     string          `json:"s,omitempty"`
+	Filter   string          `json:"f,omitempty"`
+	Backward bool            `json:"b,omitempty"`
+	Value    json.RawMessage `json:"v"`
+}
+
+// HasCursor reports whether the request continues from a cursor.
+func (q ListQuery) HasCursor() bool {
+	return q.cursor != nil
+}
+
+// Backward reports whether the cursor points at the previous page:
+// fetch items before the cursor value in reverse order, then reverse.
+func (q ListQuery) Backward() bool {
+	return q.cursor != nil && q.cursor.Backward
+}
+
+// Cursor decodes the position the handler stored with CursorPage into
+// v, typically the sort key values of the boundary item.
+func (q ListQuery) Cursor(v any) error {
+	if q.cursor == nil {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(q.cursor.Value, v); err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+func (q ListQuery) encodeCursor(v any, backward bool) (string, error) {
+	if len(q.cfg.CursorSecret) == 0 {
+		return "", errors.New("httpserver: ListConfig.CursorSecret is required for cursor pagination")
+	}
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(cursorToken{
+		Sort:     q.SortString(),
+		Filter:   q.filterString(),
+		Backward: backward,
+		Value:    value,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(q.sign(payload)), nil
+}
+
+func (q ListQuery) decodeCursor(token string) (*cursorToken, error) {
+	if len(q.cfg.CursorSecret) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	enc := base64.RawURLEncoding
+
+	rawPayload, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := enc.DecodeString(rawPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	sig, err := enc.DecodeString(rawSig)
+	if err != nil || !hmac.Equal(sig, q.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur cursorToken
+	if err := json.Unmarshal(payload, &cur); err != nil ||
+		cur.Sort != q.SortString() || cur.Filter != q.filterString() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cur, nil
+}
+
+// filterString renders the parsed filters in a canonical form:
+// sorted field[op]=v1,v2 pairs.
+func (q ListQuery) filterString() string {
+	values := make(url.Values, len(q.Filters))
+	for _, f := range q.Filters {
+		values.Add(f.Field+"["+f.Op+"]", strings.Join(f.Values, ","))
+	}
+
+	return values.Encode()
+}
+
+func (q ListQuery) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, q.cfg.CursorSecret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// Page is the list response envelope. Next and Prev are ready-to-follow
+// links; Total is only known in offset mode.
+type Page[T any] struct {
+	Items  []T    `json:"items"`
+	Total  *int64 `json:"total,omitempty"`
+	Limit  int    `json:"limit"`
+	Offset *int   `json:"offset,omitempty"`
+	Next   string `json:"next,omitempty"`
+	Prev   string `json:"prev,omitempty"`
+
+	first, last string
+}
+
+// OffsetPage builds a page for ?page=/?offset= pagination; total < 0
+// means unknown, in which case Next is set whenever the page is full.
+func OffsetPage[T any](r *http.Request, q ListQuery, items []T, total int64) Page[T] {
+	offset := q.Offset
+	p := Page[T]{Items: nonNil(items), Limit: q.Limit, Offset: &offset}
+
+	hasNext := len(items) >= q.Limit
+	if total >= 0 {
+		p.Total = &total
+		hasNext = int64(q.Offset+len(items)) < total
+	}
+
+	if hasNext {
+		p.Next = pageLink(r, q, "offset", strconv.Itoa(q.Offset+q.Limit))
+	}
+
+	if q.Offset > 0 {
+		p.Prev = pageLink(r, q, "offset", strconv.Itoa(max(q.Offset-q.Limit, 0)))
+		p.first = pageLink(r, q, "offset", "0")
+	}
+
+	if total > 0 {
+		last := (total - 1) / int64(q.Limit) * int64(q.Limit)
+		if last != int64(q.Offset) {
+			p.last = pageLink(r, q, "offset", strconv.FormatInt(last, 10))
+		}
+	}
+
+	return p
+}
+
+// CursorPage builds a page for cursor pagination. next and prev are the
+// positions to resume from (see ListQuery.Cursor); nil omits the link.
+func CursorPage[T any](r *http.Request, q ListQuery, items []T, next, prev any) (Page[T], error) {
+	p := Page[T]{Items: nonNil(items), Limit: q.Limit}
+
+	for _, l := range []struct {
+		pos      any
+		backward bool
+		dst      *string
+	}{
+		{next, false, &p.Next},
+		{prev, true, &p.Prev},
+	} {
+		if l.pos == nil {
+			continue
+		}
+
+		token, err := q.encodeCursor(l.pos, l.backward)
+		if err != nil {
+			return p, err
+		}
+
+		*l.dst = pageLink(r, q, "cursor", token)
+	}
+
+	if q.HasCursor() {
+		p.first = pageLink(r, q, "cursor", "")
+	}
+
+	return p, nil
+}
+
+// WritePage responds 200 with the page and an RFC 8288 Link header
+// carrying its next, prev, first and last relations.
+func WritePage[T any](w http.ResponseWriter, p Page[T]) {
+	var links []string
+
+	for _, l := range []struct{ rel, href string }{
+		{"next", p.Next},
+		{"prev", p.Prev},
+		{"first", p.first},
+		{"last", p.last},
+	} {
+		if l.href != "" {
+			links = append(links, "<"+l.href+`>; rel="`+l.rel+`"`)
+		}
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	OK(w, p)
+}
+
+// pageLink rewrites the request URL for another page, keeping sort and
+// filters. An empty value drops the key.
+func pageLink(r *http.Request, q ListQuery, key, value string) string {
+	query := r.URL.Query()
+	for _, k := range []string{"page", "offset", "cursor"} {
+		query.Del(k)
+	}
+
+	if value != "" {
+		query.Set(key, value)
+	}
+
+	query.Set("limit", strconv.Itoa(q.Limit))
+
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+
+	return u.String()
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
@@ -0,0 +1,120 @@
+package httpserver
+
+import (
+	"net/http"
+	"strings"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+const (
+	ContentTypeProblem = "application/problem+json"
+
+	defaultProblemTypeBase = "urn:problem-type:"
+	defaultProblemInstance = "X-Request-Id"
+)
+
+// ProblemConfig switches error output to RFC 9457 problem details.
+type ProblemConfig struct {
+	// TypeBaseURI is prefixed to the kebab-cased error code to form
+	// "type", e.g. "https://errors.example.com/" + "body-too-large".
+	TypeBaseURI string
+	// InstanceHeader is the response header whose value becomes
+	// "instance"; it is set by middleware.RequestID.
+	InstanceHeader string
+}
+
+func (c ProblemConfig) withDefaults() ProblemConfig {
+	if c.TypeBaseURI == "" {
+		c.TypeBaseURI = defaultProblemTypeBase
+	}
+
+	if c.InstanceHeader == "" {
+		c.InstanceHeader = defaultProblemInstance
+	}
+
+	return c
+}
+
+// UseProblemDetails makes Error, ErrorStatus and every framework
+// component built on them answer with application/problem+json for
+// requests served by this router and its groups. The setting belongs
+// to the router, so an admin server and the public API can differ.
+func (rt *Router) UseProblemDetails(cfg ProblemConfig) {
+	cfg = cfg.withDefaults()
+	rt.shared.problem = &cfg
+}
+
+// ProblemDetailsEnabled reports whether w is served by a router with
+// problem details on. Wrapping writers must implement Unwrap to keep
+// the setting visible, as for http.ResponseController.
+func ProblemDetailsEnabled(w http.ResponseWriter) bool {
+	return problemConfigOf(w) != nil
+}
+
+func problemConfigOf(w http.ResponseWriter) *ProblemConfig {
+	for {
+		switch x := w.(type) {
+		case *problemWriter:
+			return x.cfg
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = x.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// problemWriter carries the router's ProblemConfig down to Error.
+type problemWriter struct {
+	http.ResponseWriter
+	cfg *ProblemConfig
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *problemWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// reservedProblemMembers cannot be overwritten by error details.
+var reservedProblemMembers = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true,
+	"instance": true, "code": true,
+}
+
+func problemBody(
+	cfg *ProblemConfig, w http.ResponseWriter, status int, err error,
+) map[string]any {
+	if domainerrors.GetCode(err) == "" {
+		err = ErrInternal
+	}
+
+	pub := domainerrors.ToPublicError(err)
+
+	body := map[string]any{
+		"type":   cfg.TypeBaseURI + problemTypeName(pub.Code),
+		"title":  http.StatusText(status),
+		"status": status,
+		"detail": pub.Message,
+		"code":   pub.Code,
+	}
+
+	if id := w.Header().Get(cfg.InstanceHeader); id != "" {
+		body["instance"] = id
+	}
+
+	for k, v := range pub.Details {
+		if !reservedProblemMembers[k] {
+			body[k] = v
+		}
+	}
+
+	return body
+}
+
+func problemTypeName(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
@@ -0,0 +1,141 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// problemRecorder is a recorder served by a router with problem
+// details on.
+func problemRecorder(cfg ProblemConfig) (*httptest.ResponseRecorder, http.ResponseWriter) {
+	rr := httptest.NewRecorder()
+	cfg = cfg.withDefaults()
+
+	return rr, &problemWriter{ResponseWriter: rr, cfg: &cfg}
+}
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	assertHeader(t, "Content-Type", ContentTypeProblem, rr)
+
+	var body map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid problem body: %v", err)
+	}
+
+	return body
+}
+
+func TestProblemDetails_DomainError(t *testing.T) {
+	t.Parallel()
+	rr, w := problemRecorder(ProblemConfig{TypeBaseURI: "https://errors.example.com/"})
+	rr.Header().Set("X-Request-Id", "req-1")
+
+	Error(w, ErrBodyTooLarge.WithDetail("limit", 10))
+	assertStatus(t, http.StatusRequestEntityTooLarge, rr)
+
+	body := decodeProblem(t, rr)
+	want := map[string]any{
+		"type":     "https://errors.example.com/body-too-large",
+		"title":    "Request Entity Too Large",
+		"status":   float64(413),
+		"detail":   "request body too large",
+		"instance": "req-1",
+		"code":     "BODY_TOO_LARGE",
+		"limit":    float64(10),
+	}
+
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, body[k])
+		}
+	}
+}
+
+func TestProblemDetails_InternalError(t *testing.T) {
+	t.Parallel()
+	rr, w := problemRecorder(ProblemConfig{})
+
+	Error(w, errors.New("db password leaked"))
+
+	body := decodeProblem(t, rr)
+	if body["detail"] != "internal error" || body["type"] != "urn:problem-type:internal" ||
+		body["code"] != "INTERNAL" {
+		t.Fatalf("unexpected body: %v", body)
+	}
+
+	if _, ok := body["instance"]; ok {
+		t.Fatal("instance must be omitted without request ID")
+	}
+}
+
+func TestProblemDetails_ValidationFields(t *testing.T) {
+	t.Parallel()
+	rr, w := problemRecorder(ProblemConfig{})
+
+	Error(w, ErrValidation.WithDetail("fields", []FieldError{
+		{Field: "email", Source: "body", Rule: "required", Message: "is required"},
+	}))
+
+	body := decodeProblem(t, rr)
+	fields, ok := body["fields"].([]any)
+	if !ok || len(fields) != 1 {
+		t.Fatalf("expected fields extension, got %v", body)
+	}
+}
+
+func TestProblemDetails_ReservedMembers(t *testing.T) {
+	t.Parallel()
+	rr, w := problemRecorder(ProblemConfig{})
+
+	ErrorStatus(w, http.StatusConflict, ErrValidation.WithDetail("status", "hijack"))
+
+	if body := decodeProblem(t, rr); body["status"] != float64(409) {
+		t.Fatalf("status overwritten: %v", body["status"])
+	}
+}
+
+func TestProblemDetails_RouterErrors(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.UseProblemDetails(ProblemConfig{})
+	router.GET("/test", ok)
+
+	rr := serve(router, "PUT", "/test", nil)
+	assertStatus(t, http.StatusMethodNotAllowed, rr)
+
+	if body := decodeProblem(t, rr); body["code"] != "METHOD_NOT_ALLOWED" {
+		t.Fatalf("unexpected body: %v", body)
+	}
+
+	rr = serve(router, "GET", "/missing", nil)
+	assertStatus(t, http.StatusNotFound, rr)
+
+	if body := decodeProblem(t, rr); body["code"] != "ROUTE_NOT_FOUND" {
+		t.Fatalf("unexpected body: %v", body)
+	}
+}
+
+func TestProblemDetails_PerRouter(t *testing.T) {
+	t.Parallel()
+	fail := func(w http.ResponseWriter, _ *http.Request) { Error(w, ErrValidation) }
+
+	api := NewRouter()
+	api.UseProblemDetails(ProblemConfig{TypeBaseURI: "https://errors.example.com/"})
+	api.Group("/v1").GET("/fail", fail)
+
+	admin := NewRouter()
+	admin.GET("/fail", fail)
+
+	body := decodeProblem(t, serve(api, "GET", "/v1/fail", nil))
+	if body["type"] != "https://errors.example.com/validation-failed" {
+		t.Fatalf("unexpected problem type: %v", body["type"])
+	}
+
+	rr := serve(admin, "GET", "/fail", nil)
+	assertHeader(t, "Content-Type", "application/json", rr)
+	assertContains(t, rr.Body.String(), `"code":"VALIDATION_FAILED"`)
+}
@@ -1,10 +1,15 @@
 package httpserver
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 const DefaultMaxBodySize = 1 << 20
@@ -17,13 +22,89 @@ func QueryParam(r *http.Request, name string) string {
 	return r.URL.Query().Get(name)
 }
 
-func Bind(r *http.Request, target any) error {
-	return BindWithLimit(r, target, DefaultMaxBodySize)
+type BindOption func(*bindOptions)
+
+type bindOptions struct {
+	disallowUnknown bool
+	singleValue     bool
+	useNumber       bool
+	noDuplicates    bool
+	contentTypes    []string
+}
+
+func DisallowUnknownFields() BindOption {
+	return func(o *bindOptions) { o.disallowUnknown = true }
+}
+
+// SingleValue rejects bodies with anything but whitespace after the
+// first JSON value.
+func SingleValue() BindOption {
+	return func(o *bindOptions) { o.singleValue = true }
+}
+
+func UseNumber() BindOption {
+	return func(o *bindOptions) { o.useNumber = true }
+}
+
+// DisallowDuplicateKeys rejects objects repeating a key. Keys are
+// compared case-insensitively, as encoding/json matches them to fields,
+// so {"id":1,"ID":2} is a duplicate too.
+func DisallowDuplicateKeys() BindOption {
+	return func(o *bindOptions) { o.noDuplicates = true }
+}
+
+// RequireContentType answers 415 unless the request Content-Type is
+// one of types; with no arguments it requires application/json or any
+// +json media type.
+func RequireContentType(types ...string) BindOption {
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+
+	return func(o *bindOptions) { o.contentTypes = types }
+}
+
+func StrictJSON() BindOption {
+	return func(o *bindOptions) {
+		o.disallowUnknown = true
+		o.singleValue = true
+		o.noDuplicates = true
+		RequireContentType()(o)
+	}
+}
+
+type DecodeError struct {
+	Field    string
+	Offset   int64
+	Expected string
+	Reason   string
+}
+
+func (e *DecodeError) Error() string {
+	msg := e.Reason
+	if e.Field != "" {
+		msg = e.Field + ": " + msg
+	}
+
+	return fmt.Sprintf("%s (offset %d)", msg, e.Offset)
+}
+
+func Bind(r *http.Request, target any, opts ...BindOption) error {
+	return BindWithLimit(r, target, DefaultMaxBodySize, opts...)
 }
 
 func BindWithLimit(
-	r *http.Request, target any, maxBytes int64,
+	r *http.Request, target any, maxBytes int64, opts ...BindOption,
 ) error {
+	o := &bindOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := checkContentType(r, o.contentTypes); err != nil {
+		return err
+	}
+
 	if r.Body == nil || r.Body == http.NoBody {
 		return ErrEmptyBody
 	}
@@ -31,20 +112,205 @@ func BindWithLimit(
 	limited := http.MaxBytesReader(nil, r.Body, maxBytes)
 	defer func() { _ = limited.Close() }()
 
-	dec := json.NewDecoder(limited)
+	if dec, ok := decoderFor(r.Header.Get("Content-Type")); ok {
+		return decodeWith(dec, limited, target)
+	}
+
+	var src io.Reader = limited
+
+	if o.noDuplicates {
+		data, err := io.ReadAll(limited)
+		if err != nil {
+			return categorizeDecodeError(err)
+		}
+
+		if err := checkDuplicateKeys(json.NewDecoder(bytes.NewReader(data)), ""); err != nil {
+			return categorizeDecodeError(err)
+		}
+
+		src = bytes.NewReader(data)
+	}
+
+	return decodeJSON(src, target, o)
+}
+
+func decodeJSON(src io.Reader, target any, o *bindOptions) error {
+	dec := json.NewDecoder(src)
+
+	if o.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+
+	if o.useNumber {
+		dec.UseNumber()
+	}
 
 	if err := dec.Decode(target); err != nil {
 		return categorizeDecodeError(err)
 	}
 
+	if o.singleValue {
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return invalidJSON(&DecodeError{
+				Offset: dec.InputOffset(),
+				Reason: "unexpected data after top-level value",
+			})
+		}
+	}
+
 	return nil
 }
 
+func checkContentType(r *http.Request, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ErrUnsupportedMediaType
+	}
+
+	for _, a := range allowed {
+		if mediaType == a ||
+			(a == "application/json" && strings.HasSuffix(mediaType, "+json")) {
+			return nil
+		}
+	}
+
+	return ErrUnsupportedMediaType.WithDetail("content_type", mediaType)
+}
+
+func checkDuplicateKeys(dec *json.Decoder, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		seen := make(map[string]bool)
+
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			key, _ := keyTok.(string)
+			field := joinFieldPath(path, key)
+
+			folded := strings.ToLower(key)
+			if seen[folded] {
+				return &DecodeError{
+					Field:  field,
+					Offset: dec.InputOffset(),
+					Reason: "duplicate key",
+				}
+			}
+
+			seen[folded] = true
+
+			if err := checkDuplicateKeys(dec, field); err != nil {
+				return err
+			}
+		}
+
+		_, err = dec.Token()
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := checkDuplicateKeys(dec, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+
+		_, err = dec.Token()
+	}
+
+	return err
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
 func categorizeDecodeError(err error) error {
+	var (
+		maxBytesErr  *http.MaxBytesError
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		decodeErr    *DecodeError
+		unknownField string
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return ErrBodyTooLarge.WithDetail("limit", maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case errors.As(err, &decodeErr):
+		return invalidJSON(decodeErr)
+	case errors.As(err, &syntaxErr):
+		return invalidJSON(&DecodeError{Offset: syntaxErr.Offset, Reason: syntaxErr.Error()})
+	case errors.As(err, &typeErr):
+		return invalidJSON(&DecodeError{
+			Field:    typeErr.Field,
+			Offset:   typeErr.Offset,
+			Expected: typeErr.Type.String(),
+			Reason:   "cannot use JSON " + typeErr.Value + " as " + typeErr.Type.String(),
+		})
+	case parseUnknownField(err, &unknownField):
+		return invalidJSON(&DecodeError{Field: unknownField, Reason: "unknown field"})
+	default:
+		return invalidJSON(&DecodeError{Reason: err.Error()})
+	}
+}
+
+func decodeWith(dec Decoder, src io.Reader, target any) error {
 	var maxBytesErr *http.MaxBytesError
-	if errors.As(err, &maxBytesErr) {
-		return ErrBodyTooLarge
+
+	err := dec.Decode(src, target)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &maxBytesErr):
+		return ErrBodyTooLarge.WithDetail("limit", maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	default:
+		return ErrInvalidBody.WithCause(err).WithDetails(map[string]any{
+			"content_type": dec.ContentType(),
+			"reason":       err.Error(),
+		})
+	}
+}
+
+func parseUnknownField(err error, field *string) bool {
+	name, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return false
+	}
+
+	*field, _ = strconv.Unquote(name)
+
+	return true
+}
+
+func invalidJSON(de *DecodeError) error {
+	details := map[string]any{"offset": de.Offset, "reason": de.Reason}
+
+	if de.Field != "" {
+		details["field"] = de.Field
+	}
+
+	if de.Expected != "" {
+		details["expected"] = de.Expected
 	}
 
-	return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	return ErrInvalidJSON.WithCause(de).WithDetails(details)
 }
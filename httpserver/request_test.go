@@ -1,6 +1,7 @@
 package httpserver
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -91,3 +92,150 @@ func TestBindWithLimit_TooLarge(t *testing.T) {
 		t.Fatalf("expected ErrBodyTooLarge, got %v", err)
 	}
 }
+
+func TestBind_DisallowUnknownFields(t *testing.T) {
+	t.Parallel()
+	var target struct {
+		Name string `json:"name"`
+	}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"a","extra":1}`))
+	err := Bind(r, &target, DisallowUnknownFields())
+	de := decodeError(t, err)
+	if de.Field != "extra" || de.Reason != "unknown field" {
+		t.Fatalf("unexpected decode error: %+v", de)
+	}
+}
+
+func TestBind_SingleValue(t *testing.T) {
+	t.Parallel()
+	var target map[string]any
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":1} {"b":2}`))
+	if err := Bind(r, &target); err != nil {
+		t.Fatalf("default mode should accept trailing data, got %v", err)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"a":1} garbage`))
+	err := Bind(r, &target, SingleValue())
+	if de := decodeError(t, err); de.Offset == 0 {
+		t.Fatalf("expected offset, got %+v", de)
+	}
+}
+
+func TestBind_UseNumber(t *testing.T) {
+	t.Parallel()
+	var target map[string]any
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"n":12345678901234567890}`))
+	if err := Bind(r, &target, UseNumber()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, ok := target["n"].(json.Number); !ok || n.String() != "12345678901234567890" {
+		t.Fatalf("expected json.Number, got %T %v", target["n"], target["n"])
+	}
+}
+
+func TestBind_DuplicateKeys(t *testing.T) {
+	t.Parallel()
+	var target map[string]any
+	body := `{"user":{"id":1,"id":2}}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	de := decodeError(t, Bind(r, &target, DisallowDuplicateKeys()))
+	if de.Field != "user.id" || de.Reason != "duplicate key" {
+		t.Fatalf("unexpected decode error: %+v", de)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"id":1,"ID":2}`))
+	de = decodeError(t, Bind(r, &target, DisallowDuplicateKeys()))
+	if de.Field != "ID" || de.Reason != "duplicate key" {
+		t.Fatalf("expected case-insensitive duplicate, got %+v", de)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`[{"a":1},{"a":2}]`))
+	if err := Bind(r, &target, DisallowDuplicateKeys()); !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("expected type error for array into map, got %v", err)
+	}
+}
+
+func TestBind_TypeErrorDetails(t *testing.T) {
+	t.Parallel()
+	var target struct {
+		Age int `json:"age"`
+	}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"age":"old"}`))
+	de := decodeError(t, Bind(r, &target))
+	if de.Field != "age" || de.Expected != "int" || de.Offset == 0 {
+		t.Fatalf("unexpected decode error: %+v", de)
+	}
+}
+
+func TestBind_RequireContentType(t *testing.T) {
+	t.Parallel()
+	var target map[string]any
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "text/plain")
+	err := Bind(r, &target, RequireContentType())
+	if !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Fatalf("expected ErrUnsupportedMediaType, got %v", err)
+	}
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/merge-patch+json; charset=utf-8")
+	if err := Bind(r, &target, RequireContentType()); err != nil {
+		t.Fatalf("expected +json to be accepted, got %v", err)
+	}
+}
+
+func TestBind_StrictJSON(t *testing.T) {
+	t.Parallel()
+	var target map[string]any
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"a":1,"a":2}`))
+	r.Header.Set("Content-Type", "application/json")
+	if err := Bind(r, &target, StrictJSON()); !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("expected ErrInvalidJSON, got %v", err)
+	}
+}
+
+func TestBind_EmptyReader(t *testing.T) {
+	t.Parallel()
+	var target map[string]any
+	r := httptest.NewRequest("POST", "/", strings.NewReader(""))
+	if err := Bind(r, &target); !errors.Is(err, ErrEmptyBody) {
+		t.Fatalf("expected ErrEmptyBody, got %v", err)
+	}
+}
+
+func TestBind_ErrorStatuses(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		body   string
+		ct     string
+		opts   []BindOption
+		status int
+	}{
+		{"invalid", `{bad`, "application/json", nil, http.StatusBadRequest},
+		{"too large", `{"a":"` + strings.Repeat("x", 64) + `"}`, "", nil, http.StatusRequestEntityTooLarge},
+		{"media type", `{}`, "text/plain", []BindOption{RequireContentType()}, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler := Wrap(func(_ http.ResponseWriter, r *http.Request) error {
+				var target map[string]any
+				return BindWithLimit(r, &target, 32, tt.opts...)
+			})
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.ct)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assertStatus(t, tt.status, rr)
+		})
+	}
+}
+
+func decodeError(t *testing.T, err error) *DecodeError {
+	t.Helper()
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Fatalf("expected ErrInvalidJSON, got %v", err)
+	}
+	var de *DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("expected *DecodeError in chain, got %v", err)
+	}
+	return de
+}
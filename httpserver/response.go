@@ -10,15 +10,19 @@ import (
 )
 
 func JSON(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, "application/json", status, data)
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, status int, data any) {
 	buf, err := json.Marshal(data)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Type", contentType)
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(status)
 	_, _ = io.Copy(w, bytes.NewReader(buf))
@@ -37,7 +41,15 @@ func NoContent(w http.ResponseWriter) {
 }
 
 func Error(w http.ResponseWriter, err error) {
-	status := domainerrors.ToHTTPStatus(err)
+	ErrorStatus(w, errorHTTPStatus(err), err)
+}
+
+func ErrorStatus(w http.ResponseWriter, status int, err error) {
+	if cfg := problemConfigOf(w); cfg != nil {
+		writeJSON(w, ContentTypeProblem, status, problemBody(cfg, w, status, err))
+		return
+	}
+
 	body := domainerrors.ToPublicError(err)
 	JSON(w, status, body)
 }
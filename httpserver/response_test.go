@@ -103,3 +103,18 @@ func TestWrap_Error(t *testing.T) {
 	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
 	assertStatus(t, http.StatusBadRequest, rr)
 }
+
+func TestErrorStatus_OverridesKindStatus(t *testing.T) {
+	t.Parallel()
+	err := domainerrors.NewCode("BODY_TOO_LARGE").
+		Kind(domainerrors.Validation).
+		New("request body too large")
+	rr := httptest.NewRecorder()
+	ErrorStatus(rr, http.StatusRequestEntityTooLarge, err)
+	assertStatus(t, http.StatusRequestEntityTooLarge, rr)
+	var body domainerrors.PublicError
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	if body.Code != "BODY_TOO_LARGE" {
+		t.Fatalf("expected code 'BODY_TOO_LARGE', got %q", body.Code)
+	}
+}
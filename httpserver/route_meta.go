@@ -0,0 +1,103 @@
+package httpserver
+
+import (
+	"reflect"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+// RouteMeta documents a route for OpenAPI generation. It has no effect
+// on request handling.
+type RouteMeta struct {
+	OperationID string          `json:"operation_id,omitempty"`
+	Summary     string          `json:"summary,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Deprecated  bool            `json:"deprecated,omitempty"`
+	Hidden      bool            `json:"hidden,omitempty"`
+	Request     reflect.Type    `json:"-"`
+	Responses   []RouteResponse `json:"-"`
+	Errors      []RouteError    `json:"errors,omitempty"`
+}
+
+type RouteResponse struct {
+	Status int
+	Type   reflect.Type // nil for responses without a body
+}
+
+type RouteError struct {
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+}
+
+type RouteOption func(*RouteMeta)
+
+func WithOperationID(id string) RouteOption {
+	return func(m *RouteMeta) { m.OperationID = id }
+}
+
+func WithSummary(summary string) RouteOption {
+	return func(m *RouteMeta) { m.Summary = summary }
+}
+
+func WithDescription(description string) RouteOption {
+	return func(m *RouteMeta) { m.Description = description }
+}
+
+func WithTags(tags ...string) RouteOption {
+	return func(m *RouteMeta) { m.Tags = append(m.Tags, tags...) }
+}
+
+func WithDeprecated() RouteOption {
+	return func(m *RouteMeta) { m.Deprecated = true }
+}
+
+// WithHidden keeps the route out of generated documentation.
+func WithHidden() RouteOption {
+	return func(m *RouteMeta) { m.Hidden = true }
+}
+
+// WithRequest declares the type the handler binds with BindRequest.
+func WithRequest[T any]() RouteOption {
+	return func(m *RouteMeta) { m.Request = reflect.TypeFor[T]() }
+}
+
+// WithResponse declares a successful response body of type T.
+func WithResponse[T any](status int) RouteOption {
+	return func(m *RouteMeta) {
+		m.Responses = append(m.Responses, RouteResponse{
+			Status: status,
+			Type:   reflect.TypeFor[T](),
+		})
+	}
+}
+
+// WithEmptyResponse declares a successful response without a body,
+// e.g. 204.
+func WithEmptyResponse(status int) RouteOption {
+	return func(m *RouteMeta) {
+		m.Responses = append(m.Responses, RouteResponse{Status: status})
+	}
+}
+
+// WithErrors declares the domain errors a route may return; each is
+// documented under the status Error would answer with.
+func WithErrors(errs ...error) RouteOption {
+	return func(m *RouteMeta) {
+		for _, err := range errs {
+			m.Errors = append(m.Errors, RouteError{
+				Status: errorHTTPStatus(err),
+				Code:   string(domainerrors.GetCode(err)),
+			})
+		}
+	}
+}
+
+func newRouteMeta(opts []RouteOption) RouteMeta {
+	var m RouteMeta
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return m
+}
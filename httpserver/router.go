@@ -1,15 +1,29 @@
 package httpserver
 
-import "net/http"
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
 
 type Router struct {
 	mux        *http.ServeMux
 	prefix     string
 	middleware []Middleware
+	shared     *routerShared
+	version    *apiVersion // set on routers returned by VersionSet.Version
+}
+
+// routerShared holds state common to a router and all of its groups.
+type routerShared struct {
+	notFound         http.Handler
+	methodNotAllowed http.Handler
+	registry         routeRegistry
+	problem          *ProblemConfig
 }
 
 func NewRouter() *Router {
-	return &Router{mux: http.NewServeMux()}
+	return &Router{mux: http.NewServeMux(), shared: &routerShared{}}
 }
 
 func (rt *Router) Use(mw ...Middleware) {
@@ -29,44 +43,194 @@ func (rt *Router) Group(
 		mux:        rt.mux,
 		prefix:     rt.prefix + prefix,
 		middleware: combined,
+		shared:     rt.shared,
+		version:    rt.version,
+	}
+}
+
+// Without returns a router like rt minus the inherited middleware made
+// by the same constructors as mw, e.g. a webhook group without CSRF.
+// Closures are matched by the function that created them, so every
+// middleware.CSRF(...) instance is dropped by any other.
+func (rt *Router) Without(mw ...Middleware) *Router {
+	drop := make(map[uintptr]bool, len(mw))
+	for _, m := range mw {
+		drop[reflect.ValueOf(m).Pointer()] = true
+	}
+
+	kept := make([]Middleware, 0, len(rt.middleware))
+	for _, m := range rt.middleware {
+		if !drop[reflect.ValueOf(m).Pointer()] {
+			kept = append(kept, m)
+		}
+	}
+
+	return &Router{
+		mux:        rt.mux,
+		prefix:     rt.prefix,
+		middleware: kept,
+		shared:     rt.shared,
+		version:    rt.version,
 	}
 }
 
-func (rt *Router) GET(pattern string, h http.HandlerFunc) {
-	rt.handle("GET", pattern, h)
+// NotFound replaces the default 404 ROUTE_NOT_FOUND response. The
+// handler runs through the middleware of the router being served.
+func (rt *Router) NotFound(h http.Handler) {
+	rt.shared.notFound = h
 }
 
-func (rt *Router) POST(pattern string, h http.HandlerFunc) {
-	rt.handle("POST", pattern, h)
+// MethodNotAllowed replaces the default 405 METHOD_NOT_ALLOWED
+// response; the Allow header is already set when h runs.
+func (rt *Router) MethodNotAllowed(h http.Handler) {
+	rt.shared.methodNotAllowed = h
 }
 
-func (rt *Router) PUT(pattern string, h http.HandlerFunc) {
-	rt.handle("PUT", pattern, h)
+func (rt *Router) GET(
+	pattern string, h http.HandlerFunc, opts ...RouteOption,
+) {
+	rt.handle("GET", pattern, h, opts)
 }
 
-func (rt *Router) PATCH(pattern string, h http.HandlerFunc) {
-	rt.handle("PATCH", pattern, h)
+func (rt *Router) POST(
+	pattern string, h http.HandlerFunc, opts ...RouteOption,
+) {
+	rt.handle("POST", pattern, h, opts)
 }
 
-func (rt *Router) DELETE(pattern string, h http.HandlerFunc) {
-	rt.handle("DELETE", pattern, h)
+func (rt *Router) PUT(
+	pattern string, h http.HandlerFunc, opts ...RouteOption,
+) {
+	rt.handle("PUT", pattern, h, opts)
+}
+
+func (rt *Router) PATCH(
+	pattern string, h http.HandlerFunc, opts ...RouteOption,
+) {
+	rt.handle("PATCH", pattern, h, opts)
+}
+
+func (rt *Router) DELETE(
+	pattern string, h http.HandlerFunc, opts ...RouteOption,
+) {
+	rt.handle("DELETE", pattern, h, opts)
 }
 
 func (rt *Router) Handle(
-	method, pattern string, h http.Handler,
+	method, pattern string, h http.Handler, opts ...RouteOption,
 ) {
-	rt.handle(method, pattern, h)
+	rt.handle(method, pattern, h, opts)
 }
 
 func (rt *Router) ServeHTTP(
 	w http.ResponseWriter, r *http.Request,
 ) {
+	if cfg := rt.shared.problem; cfg != nil {
+		w = &problemWriter{ResponseWriter: w, cfg: cfg}
+	}
+
+	if _, pattern := rt.mux.Handler(r); pattern == "" {
+		applyChain(http.HandlerFunc(rt.serveUnmatched), rt.middleware).
+			ServeHTTP(w, r)
+		return
+	}
+
 	rt.mux.ServeHTTP(w, r)
 }
 
+// serveUnmatched lets the mux decide between 404 and 405 (and compute
+// Allow), then answers OPTIONS itself and hands everything else to the
+// configured handlers. Without them the mux's plain-text answers are
+// kept unless problem details are enabled.
+func (rt *Router) serveUnmatched(w http.ResponseWriter, r *http.Request) {
+	probe := &probeWriter{header: make(http.Header)}
+	rt.mux.ServeHTTP(probe, r)
+
+	if probe.status != http.StatusMethodNotAllowed {
+		rt.shared.serveNotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", withOptions(probe.header.Get("Allow")))
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch {
+	case rt.shared.methodNotAllowed != nil:
+		rt.shared.methodNotAllowed.ServeHTTP(w, r)
+	case ProblemDetailsEnabled(w):
+		Error(w, ErrMethodNotAllowed)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *routerShared) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case s.notFound != nil:
+		s.notFound.ServeHTTP(w, r)
+	case ProblemDetailsEnabled(w):
+		Error(w, ErrRouteNotFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func withOptions(allow string) string {
+	for _, m := range strings.Split(allow, ", ") {
+		if m == http.MethodOptions {
+			return allow
+		}
+	}
+
+	if allow == "" {
+		return http.MethodOptions
+	}
+
+	return allow + ", " + http.MethodOptions
+}
+
+type probeWriter struct {
+	header http.Header
+	status int
+}
+
+func (p *probeWriter) Header() http.Header { return p.header }
+
+func (p *probeWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (p *probeWriter) WriteHeader(status int) { p.status = status }
+
 func (rt *Router) handle(
-	method, pattern string, h http.Handler,
+	method, pattern string, h http.Handler, opts []RouteOption,
 ) {
-	full := method + " " + rt.prefix + pattern
-	rt.mux.Handle(full, applyChain(h, rt.middleware))
+	if rt.version != nil {
+		rt.version.handle(method, rt.prefix, pattern, applyChain(h, rt.middleware))
+	} else {
+		rt.mux.Handle(method+" "+rt.prefix+pattern, applyChain(h, rt.middleware))
+	}
+
+	name := funcName(h)
+	if d, ok := h.(describedHandler); ok {
+		name = d.handlerName()
+		opts = append(d.routeOptions(), opts...)
+	}
+
+	var version string
+	if rt.version != nil {
+		version = rt.version.name
+	}
+
+	rt.shared.registry.add(Route{
+		Method:     method,
+		Pattern:    rt.prefix + pattern,
+		Prefix:     rt.prefix,
+		Version:    version,
+		Middleware: middlewareNames(rt.middleware),
+		Handler:    name,
+		Meta:       newRouteMeta(opts),
+	})
 }
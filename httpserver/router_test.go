@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -21,7 +22,7 @@ func TestRouter_GET(t *testing.T) {
 func TestRouter_Methods(t *testing.T) {
 	t.Parallel()
 	methods := []struct {
-		register func(*Router, string, http.HandlerFunc)
+		register func(*Router, string, http.HandlerFunc, ...RouteOption)
 		method   string
 	}{
 		{(*Router).GET, "GET"},
@@ -80,6 +81,21 @@ func TestRouter_Group_Middleware(t *testing.T) {
 	assertHeader(t, "X-Group", "yes", rr)
 }
 
+func TestRouter_Without(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.Use(headerMiddleware("X-Global", "yes"))
+	hooks := router.Without(headerMiddleware("X-Other", "no")).
+		Group("/hooks", headerMiddleware("X-Group", "yes"))
+	hooks.POST("/stripe", ok)
+	router.POST("/orders", ok)
+
+	rr := serve(router, "POST", "/hooks/stripe", nil)
+	assertHeader(t, "X-Global", "", rr)
+	assertHeader(t, "X-Group", "yes", rr)
+	assertHeader(t, "X-Global", "yes", serve(router, "POST", "/orders", nil))
+}
+
 func TestRouter_PathParam(t *testing.T) {
 	t.Parallel()
 	router := NewRouter()
@@ -90,6 +106,69 @@ func TestRouter_PathParam(t *testing.T) {
 	assertBody(t, "42", rr)
 }
 
+func TestRouter_CustomNotFound_RunsMiddleware(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.Use(headerMiddleware("X-Global", "yes"))
+	router.NotFound(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		JSON(w, http.StatusNotFound, map[string]string{"error": "nope"})
+	}))
+	router.Group("/api").GET("/test", ok)
+	rr := serve(router, "GET", "/missing", nil)
+	assertStatus(t, http.StatusNotFound, rr)
+	assertHeader(t, "X-Global", "yes", rr)
+	assertBody(t, `{"error":"nope"}`, rr)
+}
+
+func TestRouter_CustomMethodNotAllowed(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.GET("/test", ok)
+	router.Group("/api").MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rr := serve(router, "POST", "/test", nil)
+	assertStatus(t, http.StatusTeapot, rr)
+	assertHeader(t, "Allow", "GET, HEAD, OPTIONS", rr)
+}
+
+func TestRouter_AutoOptions(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.Use(headerMiddleware("X-Global", "yes"))
+	router.GET("/items/{id}", ok)
+	router.DELETE("/items/{id}", ok)
+	rr := serve(router, "OPTIONS", "/items/1", nil)
+	assertStatus(t, http.StatusNoContent, rr)
+	assertHeader(t, "Allow", "DELETE, GET, HEAD, OPTIONS", rr)
+	assertHeader(t, "X-Global", "yes", rr)
+
+	rr = serve(router, "OPTIONS", "/missing", nil)
+	assertStatus(t, http.StatusNotFound, rr)
+}
+
+func TestRouter_ExplicitOptionsWins(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.GET("/test", ok)
+	router.Handle("OPTIONS", "/test", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	rr := serve(router, "OPTIONS", "/test", nil)
+	assertStatus(t, http.StatusAccepted, rr)
+}
+
+func TestRouter_AutoHead(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.GET("/test", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Handler", "get")
+	})
+	rr := serve(router, "HEAD", "/test", nil)
+	assertStatus(t, http.StatusOK, rr)
+	assertHeader(t, "X-Handler", "get", rr)
+}
+
 func ok(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
@@ -130,3 +209,30 @@ func assertHeader(t *testing.T, key, expected string, rr *httptest.ResponseRecor
 		t.Errorf("header %s: expected %q, got %q", key, expected, v)
 	}
 }
+
+func TestRouter_NotFound(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.GET("/test", ok)
+	rr := serve(router, "GET", "/missing", nil)
+	assertStatus(t, http.StatusNotFound, rr)
+	assertBody(t, "404 page not found\n", rr)
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.GET("/test", ok)
+	router.POST("/test", ok)
+	rr := serve(router, "DELETE", "/test", nil)
+	assertStatus(t, http.StatusMethodNotAllowed, rr)
+	assertHeader(t, "Allow", "GET, HEAD, POST, OPTIONS", rr)
+	assertBody(t, "Method Not Allowed\n", rr)
+}
+
+func assertContains(t *testing.T, s, substr string) {
+	t.Helper()
+	if !strings.Contains(s, substr) {
+		t.Errorf("expected %q to contain %q", s, substr)
+	}
+}
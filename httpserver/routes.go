@@ -0,0 +1,97 @@
+package httpserver
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Route describes a registered route as seen by introspection tools.
+type Route struct {
+	Method     string    `json:"method"`
+	Pattern    string    `json:"pattern"`
+	Prefix     string    `json:"prefix"`
+	Version    string    `json:"version,omitempty"`
+	Middleware []string  `json:"middleware"`
+	Handler    string    `json:"handler"`
+	Meta       RouteMeta `json:"meta"`
+}
+
+type routeRegistry struct {
+	mu     sync.Mutex
+	routes []Route
+}
+
+func (rr *routeRegistry) add(route Route) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.routes = append(rr.routes, route)
+}
+
+// Routes returns every route registered on the router or any of its
+// groups, sorted by pattern and method.
+func (rt *Router) Routes() []Route {
+	reg := &rt.shared.registry
+
+	reg.mu.Lock()
+	routes := make([]Route, len(reg.routes))
+	copy(routes, reg.routes)
+	reg.mu.Unlock()
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+func middlewareNames(mw []Middleware) []string {
+	names := make([]string, len(mw))
+	for i, m := range mw {
+		names[i] = funcName(m)
+	}
+
+	return names
+}
+
+// funcName turns a function into a short "pkg.Func" name; closures are
+// attributed to the function that created them, so middleware.RequestID()
+// is reported as "middleware.RequestID".
+func funcName(v any) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Func {
+		return fmt.Sprintf("%T", v)
+	}
+
+	fn := runtime.FuncForPC(rv.Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+
+	for {
+		i := strings.LastIndex(name, ".")
+		if i < 0 || !isClosureSuffix(name[i+1:]) {
+			return name
+		}
+
+		name = name[:i]
+	}
+}
+
+func isClosureSuffix(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type structHandler struct{}
+
+func (structHandler) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+func (structHandler) list(http.ResponseWriter, *http.Request) {}
+
+func namedMiddleware() Middleware {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+func TestRouter_Routes(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.Use(namedMiddleware())
+	router.GET("/health", ok)
+
+	api := router.Group("/api", headerMiddleware("X", "y"))
+	api.POST("/users", structHandler{}.list)
+	api.Handle("GET", "/users", structHandler{})
+
+	want := []Route{
+		{
+			Method: "GET", Pattern: "/api/users", Prefix: "/api",
+			Middleware: []string{"httpserver.namedMiddleware", "httpserver.headerMiddleware"},
+			Handler:    "httpserver.structHandler",
+		},
+		{
+			Method: "POST", Pattern: "/api/users", Prefix: "/api",
+			Middleware: []string{"httpserver.namedMiddleware", "httpserver.headerMiddleware"},
+			Handler:    "httpserver.structHandler.list",
+		},
+		{
+			Method: "GET", Pattern: "/health", Prefix: "",
+			Middleware: []string{"httpserver.namedMiddleware"},
+			Handler:    "httpserver.ok",
+		},
+	}
+
+	if got := router.Routes(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("routes mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRouter_Routes_Copy(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.GET("/a", ok)
+
+	routes := router.Routes()
+	routes[0].Pattern = "/changed"
+
+	if router.Routes()[0].Pattern != "/a" {
+		t.Fatal("Routes must return a copy")
+	}
+}
+
+func TestFuncName_Closure(t *testing.T) {
+	t.Parallel()
+	inline := func(http.ResponseWriter, *http.Request) {}
+
+	if got := funcName(inline); got != "httpserver.TestFuncName_Closure" {
+		t.Fatalf("unexpected name %q", got)
+	}
+}
@@ -2,76 +2,166 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
-	"fmt"
 	"net"
 	"net/http"
 )
 
 type Module struct {
-	handler  http.Handler
-	cfg      Config
-	listener net.Listener
-	server   *http.Server
-	errCh    chan error
+	handler   http.Handler
+	cfg       Config
+	listeners []net.Listener
+	server    *http.Server
+	tls       *tlsReloader
+	stopTLS   context.CancelFunc
+	track     *tracker
+	log       Logger
+	errCh     chan error
 }
 
 func NewModule(handler http.Handler, cfg Config) *Module {
+	var log Logger = noopLogger{}
+	if cfg.Logger != nil {
+		log = cfg.Logger
+	}
+
 	return &Module{
 		handler: handler,
 		cfg:     cfg.withDefaults(),
+		track:   newTracker(),
+		log:     log,
 		errCh:   make(chan error, 1),
 	}
 }
 
-func (m *Module) Name() string { return "httpserver" }
+// Name is Config.Name, so several servers (public, admin, internal)
+// can be registered in one app.
+func (m *Module) Name() string { return m.cfg.Name }
 
 func (m *Module) Init(_ context.Context) error {
-	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+	handler := m.handler
 
-	ln, err := net.Listen("tcp", addr)
-	if err != nil {
-		return fmt.Errorf("httpserver: listen %s: %w", addr, err)
+	if m.cfg.TLS != nil {
+		rl, err := newTLSReloader(*m.cfg.TLS)
+		if err != nil {
+			return err
+		}
+
+		m.tls = rl
+		handler = withClientIdentity(handler)
+	}
+
+	for _, lc := range m.cfg.listeners() {
+		lns, err := listenWithHandoff(lc, m.cfg.Handoff)
+		if err != nil {
+			m.closeListeners()
+			return err
+		}
+
+		m.listeners = append(m.listeners, lns...)
+	}
+
+	if m.tls != nil {
+		for i, ln := range m.listeners {
+			m.listeners[i] = tls.NewListener(ln, m.tls.serverConfig())
+		}
 	}
 
-	m.listener = ln
 	m.server = &http.Server{
-		Handler:      m.handler,
-		ReadTimeout:  m.cfg.ReadTimeout,
-		WriteTimeout: m.cfg.WriteTimeout,
-		IdleTimeout:  m.cfg.IdleTimeout,
+		Handler:        m.track.wrap(handler),
+		ConnState:      m.track.connState,
+		BaseContext:    m.track.baseContext,
+		ReadTimeout:    m.cfg.ReadTimeout,
+		WriteTimeout:   m.cfg.WriteTimeout,
+		IdleTimeout:    m.cfg.IdleTimeout,
+		MaxHeaderBytes: m.cfg.MaxHeaderBytes,
+		Protocols:      m.protocols(),
 	}
 
 	return nil
 }
 
+func (m *Module) protocols() *http.Protocols {
+	if !m.cfg.H2C {
+		return nil
+	}
+
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(true)
+
+	return p
+}
+
+func (m *Module) closeListeners() {
+	for _, ln := range m.listeners {
+		_ = ln.Close()
+	}
+
+	m.listeners = nil
+}
+
 func (m *Module) Start(_ context.Context) error {
-	go func() {
-		err := m.server.Serve(m.listener)
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			m.errCh <- err
-		}
-	}()
+	if m.tls != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.stopTLS = cancel
+
+		go m.tls.watch(ctx)
+	}
+
+	for _, ln := range m.listeners {
+		go func() {
+			err := m.server.Serve(ln)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				select {
+				case m.errCh <- err:
+				default:
+				}
+			}
+		}()
+	}
+
+	m.track.ready.Store(true)
 
 	return nil
 }
 
 func (m *Module) Stop(ctx context.Context) error {
+	if m.stopTLS != nil {
+		m.stopTLS()
+	}
+
 	if m.server == nil {
 		return nil
 	}
 
-	return m.server.Shutdown(ctx)
+	err := m.drain(ctx)
+	// Listeners that never reached Serve are not closed by Shutdown.
+	m.closeListeners()
+
+	return err
 }
 
 func (m *Module) Err() <-chan error {
 	return m.errCh
 }
 
+// Addr returns the address of the first listener.
 func (m *Module) Addr() string {
-	if m.listener != nil {
-		return m.listener.Addr().String()
+	if len(m.listeners) > 0 {
+		return m.listeners[0].Addr().String()
 	}
 
 	return ""
 }
+
+func (m *Module) Addrs() []string {
+	addrs := make([]string, len(m.listeners))
+	for i, ln := range m.listeners {
+		addrs[i] = ln.Addr().String()
+	}
+
+	return addrs
+}
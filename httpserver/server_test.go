@@ -16,6 +16,14 @@ func TestModule_Name(t *testing.T) {
 	}
 }
 
+func TestModule_CustomName(t *testing.T) {
+	t.Parallel()
+	m := NewModule(http.NewServeMux(), Config{Name: "internal"})
+	if m.Name() != "internal" {
+		t.Fatalf("expected 'internal', got %q", m.Name())
+	}
+}
+
 func TestModule_Lifecycle(t *testing.T) {
 	router := NewRouter()
 	router.GET("/ping", func(w http.ResponseWriter, _ *http.Request) {
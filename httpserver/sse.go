@@ -0,0 +1,220 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+const DefaultSSEHeartbeat = 15 * time.Second
+
+var ErrStreamingUnsupported = domainerrors.NewCode("STREAMING_UNSUPPORTED").
+	Kind(domainerrors.Internal).
+	New("response writer does not support streaming")
+
+type SSEConfig struct {
+	// Heartbeat is the interval of comment lines that keep proxies from
+	// closing an idle stream; 0 = DefaultSSEHeartbeat, negative disables.
+	Heartbeat time.Duration
+	// Retry, when set, is sent to the client as the reconnection delay.
+	Retry time.Duration
+}
+
+// Event is a single server-sent event. Data that is not a string or
+// []byte is JSON-encoded; multi-line data is split into data fields.
+type Event struct {
+	ID    string
+	Event string
+	Data  any
+}
+
+type SSEStream struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	r           *http.Request
+	rc          *http.ResponseController
+	lastEventID string
+	cancel      context.CancelFunc
+}
+
+// SSE streams events produced by fn. The write deadline set by
+// Config.WriteTimeout is lifted for the stream, and fn's context is
+// cancelled when the client disconnects or a write fails. An error
+// returned by fn is sent as an "error" event.
+func SSE(
+	cfg SSEConfig, fn func(ctx context.Context, s *SSEStream) error,
+) http.HandlerFunc {
+	if cfg.Heartbeat == 0 {
+		cfg.Heartbeat = DefaultSSEHeartbeat
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+
+		if err := rc.Flush(); errors.Is(err, http.ErrNotSupported) {
+			h.Del("Content-Type")
+			Error(w, ErrStreamingUnsupported)
+			return
+		}
+
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		ctx, cancel := context.WithCancel(r.Context())
+
+		var wg sync.WaitGroup
+
+		// The heartbeat must stop before the handler returns: writes
+		// after that point are not allowed.
+		defer wg.Wait()
+		defer cancel()
+
+		s := &SSEStream{
+			w:           w,
+			r:           r,
+			rc:          rc,
+			lastEventID: r.Header.Get("Last-Event-ID"),
+			cancel:      cancel,
+		}
+
+		if cfg.Retry > 0 {
+			_ = s.write(fmt.Sprintf("retry: %d\n\n", cfg.Retry.Milliseconds()))
+		}
+
+		// Draining ends the stream; the client reconnects elsewhere
+		// with Last-Event-ID.
+		if drain := Draining(r.Context()); drain != nil {
+			go func() {
+				select {
+				case <-drain:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		if cfg.Heartbeat > 0 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				s.heartbeat(ctx, cfg.Heartbeat)
+			}()
+		}
+
+		if err := fn(ctx, s); err != nil && ctx.Err() == nil {
+			_ = s.Send(Event{Event: "error", Data: domainerrors.ToPublicError(err)})
+		}
+	}
+}
+
+// Request is the request that opened the stream, for path and query
+// parameters.
+func (s *SSEStream) Request() *http.Request {
+	return s.r
+}
+
+// LastEventID is the ID the reconnecting client saw last, or "".
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+func (s *SSEStream) Send(ev Event) error {
+	var b strings.Builder
+
+	if ev.ID != "" {
+		b.WriteString("id: " + singleLine(ev.ID) + "\n")
+	}
+
+	if ev.Event != "" {
+		b.WriteString("event: " + singleLine(ev.Event) + "\n")
+	}
+
+	data, err := eventData(ev.Data)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// Comment writes a comment line, ignored by EventSource clients.
+func (s *SSEStream) Comment(text string) error {
+	return s.write(": " + singleLine(text) + "\n\n")
+}
+
+func (s *SSEStream) heartbeat(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Comment("ping"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *SSEStream) write(chunk string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write([]byte(chunk))
+	if err == nil {
+		err = s.rc.Flush()
+	}
+
+	if err != nil {
+		s.cancel()
+		return fmt.Errorf("httpserver: sse write: %w", err)
+	}
+
+	return nil
+}
+
+func eventData(data any) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return normalizeNewlines(v), nil
+	case []byte:
+		return normalizeNewlines(string(v)), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("httpserver: sse data: %w", err)
+		}
+
+		return string(b), nil
+	}
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,136 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSE_SendsEvents(t *testing.T) {
+	t.Parallel()
+	h := SSE(SSEConfig{Heartbeat: -1, Retry: 3 * time.Second}, func(_ context.Context, s *SSEStream) error {
+		_ = s.Send(Event{ID: "1", Event: "status", Data: map[string]string{"state": "paid"}})
+		return s.Send(Event{Data: "line1\nline2"})
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/events", nil))
+
+	assertHeader(t, "Content-Type", "text/event-stream", rr)
+	assertHeader(t, "Cache-Control", "no-cache", rr)
+	want := "retry: 3000\n\n" +
+		"id: 1\nevent: status\ndata: {\"state\":\"paid\"}\n\n" +
+		"data: line1\ndata: line2\n\n"
+	assertBody(t, want, rr)
+}
+
+func TestSSE_LastEventIDAndError(t *testing.T) {
+	t.Parallel()
+	var lastID string
+	h := SSE(SSEConfig{Heartbeat: -1}, func(_ context.Context, s *SSEStream) error {
+		lastID = s.LastEventID() + s.Request().URL.Query().Get("x")
+		return ErrValidation
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/events?x=!", nil)
+	req.Header.Set("Last-Event-ID", "42")
+	h.ServeHTTP(rr, req)
+
+	if lastID != "42!" {
+		t.Fatalf("expected Last-Event-ID and request, got %q", lastID)
+	}
+	assertContains(t, rr.Body.String(), "event: error\ndata: {\"code\":\"VALIDATION_FAILED\"")
+}
+
+type plainWriter struct{ http.ResponseWriter }
+
+func TestSSE_StreamingUnsupported(t *testing.T) {
+	t.Parallel()
+	called := false
+	h := SSE(SSEConfig{}, func(context.Context, *SSEStream) error {
+		called = true
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(plainWriter{rr}, httptest.NewRequest("GET", "/events", nil))
+
+	if called {
+		t.Fatal("fn must not run without flush support")
+	}
+	assertStatus(t, http.StatusInternalServerError, rr)
+	assertHeader(t, "Content-Type", "application/json", rr)
+}
+
+func TestSSE_HeartbeatAndDisconnect(t *testing.T) {
+	t.Parallel()
+	done := make(chan error, 1)
+	srv := httptest.NewServer(SSE(SSEConfig{Heartbeat: 10 * time.Millisecond},
+		func(ctx context.Context, s *SSEStream) error {
+			_ = s.Send(Event{Data: "hello"})
+			<-ctx.Done()
+			done <- ctx.Err()
+			return nil
+		}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	reader := bufio.NewReader(resp.Body)
+	var got strings.Builder
+	for !strings.Contains(got.String(), ": ping") {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v (got %q)", err, got.String())
+		}
+		got.WriteString(line)
+	}
+	assertContains(t, got.String(), "data: hello\n")
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected ctx error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream context not cancelled on disconnect")
+	}
+}
+
+func TestSSE_LiftsWriteDeadline(t *testing.T) {
+	t.Parallel()
+	h := SSE(SSEConfig{Heartbeat: -1}, func(ctx context.Context, s *SSEStream) error {
+		time.Sleep(150 * time.Millisecond)
+		return s.Send(Event{Data: "late"})
+	})
+
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || body != "data: late\n" {
+		t.Fatalf("stream cut by write timeout: %q, %v", body, err)
+	}
+}
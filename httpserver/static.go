@@ -0,0 +1,177 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultStaticCacheControl = "public, max-age=3600"
+	DefaultIndexCacheControl  = "no-cache"
+	ImmutableCacheControl     = "public, max-age=31536000, immutable"
+)
+
+// StaticConfig serves files from FS, typically an embed.FS with a built
+// frontend (use fs.Sub to drop the embed directory prefix).
+type StaticConfig struct {
+	FS    fs.FS
+	Index string // "" = "index.html"
+	// SPA serves the root Index for missing paths without an extension,
+	// leaving client-side routing to the frontend.
+	SPA               bool
+	CacheControl      string // "" = DefaultStaticCacheControl
+	IndexCacheControl string // "" = DefaultIndexCacheControl
+	// Immutable are path.Match patterns of content-hashed assets, e.g.
+	// "assets/*"; they get ImmutableCacheControl.
+	Immutable []string
+}
+
+func (c StaticConfig) withDefaults() StaticConfig {
+	if c.Index == "" {
+		c.Index = "index.html"
+	}
+
+	if c.CacheControl == "" {
+		c.CacheControl = DefaultStaticCacheControl
+	}
+
+	if c.IndexCacheControl == "" {
+		c.IndexCacheControl = DefaultIndexCacheControl
+	}
+
+	return c
+}
+
+// Static returns a GET/HEAD handler for cfg.FS. Responses carry a
+// content-hash ETag, so embedded files (which have no modification
+// time) still revalidate with 304. Directories are never listed.
+func Static(cfg StaticConfig) http.Handler {
+	return &staticHandler{cfg: cfg.withDefaults()}
+}
+
+type staticHandler struct {
+	cfg   StaticConfig
+	etags sync.Map // staticKey -> string
+}
+
+type staticKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (s *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		Error(w, ErrMethodNotAllowed)
+
+		return
+	}
+
+	name, fi, err := s.resolve(r.URL.Path)
+	if err != nil {
+		fileError(w, err)
+		return
+	}
+
+	f, err := s.cfg.FS.Open(name)
+	if err != nil {
+		fileError(w, err)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			fileError(w, err)
+			return
+		}
+
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := s.etag(name, fi, content)
+	if err != nil {
+		fileError(w, err)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Cache-Control", s.cacheControl(name))
+	h.Set("ETag", etag)
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, name, fi.ModTime(), content)
+}
+
+// resolve maps a URL path to a file: directories serve their Index,
+// missing extensionless paths fall back to the root Index in SPA mode.
+func (s *staticHandler) resolve(urlPath string) (string, fs.FileInfo, error) {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+
+	fi, err := fs.Stat(s.cfg.FS, name)
+	if err == nil && fi.IsDir() {
+		name = path.Join(name, s.cfg.Index)
+		fi, err = fs.Stat(s.cfg.FS, name)
+	}
+
+	if errors.Is(err, fs.ErrNotExist) && s.cfg.SPA && path.Ext(name) == "" {
+		name = s.cfg.Index
+		fi, err = fs.Stat(s.cfg.FS, name)
+	}
+
+	if err == nil && fi.IsDir() {
+		err = fs.ErrNotExist
+	}
+
+	return name, fi, err
+}
+
+func (s *staticHandler) etag(name string, fi fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := staticKey{name: name, size: fi.Size(), modTime: fi.ModTime()}
+	if v, ok := s.etags.Load(key); ok {
+		return v.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	s.etags.Store(key, etag)
+
+	return etag, nil
+}
+
+func (s *staticHandler) cacheControl(name string) string {
+	if path.Base(name) == s.cfg.Index {
+		return s.cfg.IndexCacheControl
+	}
+
+	for _, pattern := range s.cfg.Immutable {
+		if ok, _ := path.Match(pattern, name); ok {
+			return ImmutableCacheControl
+		}
+	}
+
+	return s.cfg.CacheControl
+}
@@ -0,0 +1,174 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultTLSReloadInterval = 30 * time.Second
+
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16
+	// CipherSuites restricts TLS 1.2 suites; TLS 1.3 suites are fixed.
+	CipherSuites     []uint16
+	CurvePreferences []tls.CurveID
+	// ClientCAFile enables mTLS: client certificates are verified
+	// against this PEM bundle.
+	ClientCAFile string
+	// ClientAuth defaults to RequireAndVerifyClientCert when
+	// ClientCAFile is set.
+	ClientAuth tls.ClientAuthType
+	// ReloadInterval is how often the files are checked for changes;
+	// 0 = DefaultTLSReloadInterval, negative disables reloading.
+	ReloadInterval time.Duration
+	// OnReloadError is called when changed files cannot be loaded; the
+	// previous certificates stay in use.
+	OnReloadError func(error)
+}
+
+// tlsReloader serves the most recently loaded certificate and client
+// CA pool, and reloads them when the files' modification times change.
+type tlsReloader struct {
+	cfg     TLSConfig
+	current atomic.Pointer[tls.Config]
+	stamp   string
+}
+
+func newTLSReloader(cfg TLSConfig) (*tlsReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("httpserver: tls: CertFile and KeyFile are required")
+	}
+
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+
+	if cfg.ClientCAFile != "" && cfg.ClientAuth == tls.NoClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if cfg.ReloadInterval == 0 {
+		cfg.ReloadInterval = DefaultTLSReloadInterval
+	}
+
+	rl := &tlsReloader{cfg: cfg}
+	if err := rl.load(); err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// serverConfig is installed on http.Server; every handshake picks up
+// the current certificate and CA pool through GetConfigForClient.
+func (rl *tlsReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: rl.cfg.MinVersion,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return rl.current.Load(), nil
+		},
+	}
+}
+
+func (rl *tlsReloader) load() error {
+	stamp, err := rl.fileStamp()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(rl.cfg.CertFile, rl.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("httpserver: tls: load key pair: %w", err)
+	}
+
+	next := &tls.Config{
+		Certificates:     []tls.Certificate{cert},
+		MinVersion:       rl.cfg.MinVersion,
+		CipherSuites:     rl.cfg.CipherSuites,
+		CurvePreferences: rl.cfg.CurvePreferences,
+		ClientAuth:       rl.cfg.ClientAuth,
+		NextProtos:       []string{"h2", "http/1.1"},
+	}
+
+	if rl.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(rl.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("httpserver: tls: read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("httpserver: tls: no certificates in client CA file")
+		}
+
+		next.ClientCAs = pool
+	}
+
+	rl.current.Store(next)
+	rl.stamp = stamp
+
+	return nil
+}
+
+// fileStamp summarises size and mtime of every watched file.
+func (rl *tlsReloader) fileStamp() (string, error) {
+	var stamp string
+
+	for _, path := range []string{rl.cfg.CertFile, rl.cfg.KeyFile, rl.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("httpserver: tls: %w", err)
+		}
+
+		stamp += fmt.Sprintf("%s:%d:%d;", path, fi.Size(), fi.ModTime().UnixNano())
+	}
+
+	return stamp, nil
+}
+
+func (rl *tlsReloader) reloadIfChanged() {
+	stamp, err := rl.fileStamp()
+	if err == nil && stamp == rl.stamp {
+		return
+	}
+
+	if err == nil {
+		err = rl.load()
+	}
+
+	if err != nil && rl.cfg.OnReloadError != nil {
+		rl.cfg.OnReloadError(err)
+	}
+}
+
+func (rl *tlsReloader) watch(ctx context.Context) {
+	if rl.cfg.ReloadInterval < 0 {
+		return
+	}
+
+	ticker := time.NewTicker(rl.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rl.reloadIfChanged()
+		}
+	}
+}
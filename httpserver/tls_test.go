@@ -0,0 +1,239 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM-encoded certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, cn string, client bool) ([]byte, []byte) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if client {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		tmpl.IPAddresses = nil
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startTLSModule(t *testing.T, cfg *TLSConfig, h http.Handler) *Module {
+	t.Helper()
+	m := NewModule(h, Config{Host: "127.0.0.1", TLS: cfg})
+	assertNoErr(t, m.Init(context.Background()))
+	assertNoErr(t, m.Start(context.Background()))
+	t.Cleanup(func() { _ = m.Stop(context.Background()) })
+
+	return m
+}
+
+func tlsClient(ca *testCA, clientCert *tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	return &http.Client{
+		Timeout:   2 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true, ForceAttemptHTTP2: true},
+	}
+}
+
+func TestModule_TLS(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server-1", false)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	m := startTLSModule(t, &TLSConfig{
+		CertFile: certFile, KeyFile: keyFile, ReloadInterval: 10 * time.Millisecond,
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+
+	serverCN := func() string {
+		resp, err := tlsClient(ca, nil).Get("https://" + m.Addr() + "/")
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "HTTP/2.0" {
+			t.Errorf("expected HTTP/2 over TLS, got %s", body)
+		}
+
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if cn := serverCN(); cn != "server-1" {
+		t.Fatalf("unexpected certificate %q", cn)
+	}
+
+	certPEM, keyPEM = ca.issue(t, "server-2-with-longer-name", false)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, certFile, certPEM)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for serverCN() != "server-2-with-longer-name" {
+		if time.Now().After(deadline) {
+			t.Fatal("certificate was not reloaded")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestModule_MutualTLS(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server", false)
+	writeFile(t, filepath.Join(dir, "cert.pem"), certPEM)
+	writeFile(t, filepath.Join(dir, "key.pem"), keyPEM)
+	writeFile(t, filepath.Join(dir, "ca.pem"), ca.pem)
+
+	m := startTLSModule(t, &TLSConfig{
+		CertFile:     filepath.Join(dir, "cert.pem"),
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+		MinVersion:   tls.VersionTLS13,
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := ClientIdentityFromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, id.CommonName)
+	}))
+
+	if _, err := tlsClient(ca, nil).Get("https://" + m.Addr() + "/"); err == nil {
+		t.Fatal("expected handshake failure without client certificate")
+	}
+
+	clientPEM, clientKey := ca.issue(t, "billing-service", true)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := tlsClient(ca, &clientCert).Get("https://" + m.Addr() + "/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "billing-service" {
+		t.Fatalf("unexpected identity %q", body)
+	}
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Fatalf("expected TLS 1.3, got %x", resp.TLS.Version)
+	}
+}
+
+func TestTLSReloader_KeepsCertOnError(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server", false)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	var reloadErr error
+	rl, err := newTLSReloader(TLSConfig{
+		CertFile: certFile, KeyFile: keyFile,
+		OnReloadError: func(err error) { reloadErr = err },
+	})
+	assertNoErr(t, err)
+	before := rl.current.Load()
+
+	writeFile(t, certFile, []byte("garbage"))
+	rl.reloadIfChanged()
+
+	if reloadErr == nil || rl.current.Load() != before {
+		t.Fatalf("expected error and unchanged config, got %v", reloadErr)
+	}
+}
+
+func TestModule_TLS_InitErrors(t *testing.T) {
+	t.Parallel()
+	m := NewModule(http.NewServeMux(), Config{Host: "127.0.0.1", TLS: &TLSConfig{}})
+	if err := m.Init(context.Background()); err == nil {
+		t.Fatal("expected error without cert files")
+	}
+
+	m = NewModule(http.NewServeMux(), Config{Host: "127.0.0.1", TLS: &TLSConfig{
+		CertFile: "/nonexistent/cert.pem", KeyFile: "/nonexistent/key.pem",
+	}})
+	if err := m.Init(context.Background()); err == nil {
+		t.Fatal("expected error for missing files")
+	}
+}
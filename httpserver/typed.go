@@ -0,0 +1,115 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// Empty marks a typed handler without input or without a response
+// body.
+type Empty struct{}
+
+type TypedOption func(*typedConfig)
+
+type typedConfig struct {
+	status    int
+	bindOpts  []BindOption
+	routeOpts []RouteOption
+}
+
+// WithStatus sets the success status (default 200). With 204 the
+// handler's result is discarded and no body is written.
+func WithStatus(status int) TypedOption {
+	return func(c *typedConfig) { c.status = status }
+}
+
+func WithBindOptions(opts ...BindOption) TypedOption {
+	return func(c *typedConfig) { c.bindOpts = append(c.bindOpts, opts...) }
+}
+
+// WithRouteOptions adds documentation on top of what is derived from
+// In, Out and the status.
+func WithRouteOptions(opts ...RouteOption) TypedOption {
+	return func(c *typedConfig) { c.routeOpts = append(c.routeOpts, opts...) }
+}
+
+// TypedHandler adapts a use-case function to http.Handler. Register it
+// with Router.Handle so its types feed Routes and OpenAPI.
+type TypedHandler[In, Out any] struct {
+	fn  func(context.Context, In) (Out, error)
+	cfg typedConfig
+}
+
+// Handle binds and validates In with BindRequest (skipped for Empty),
+// calls fn and encodes Out with Respond; every error goes to Error.
+func Handle[In, Out any](
+	fn func(context.Context, In) (Out, error), opts ...TypedOption,
+) *TypedHandler[In, Out] {
+	cfg := typedConfig{status: http.StatusOK}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &TypedHandler[In, Out]{fn: fn, cfg: cfg}
+}
+
+func (h *TypedHandler[In, Out]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in In
+
+	if !isEmpty[In]() {
+		var err error
+		if in, err = BindRequest[In](r, h.cfg.bindOpts...); err != nil {
+			Error(w, err)
+			return
+		}
+	}
+
+	out, err := h.fn(r.Context(), in)
+	if err != nil {
+		Error(w, err)
+		return
+	}
+
+	if h.noBody() {
+		w.WriteHeader(h.cfg.status)
+		return
+	}
+
+	Respond(w, r, h.cfg.status, out)
+}
+
+func (h *TypedHandler[In, Out]) noBody() bool {
+	return h.cfg.status == http.StatusNoContent || isEmpty[Out]()
+}
+
+func (h *TypedHandler[In, Out]) routeOptions() []RouteOption {
+	opts := make([]RouteOption, 0, len(h.cfg.routeOpts)+2)
+
+	if !isEmpty[In]() {
+		opts = append(opts, WithRequest[In]())
+	}
+
+	if h.noBody() {
+		opts = append(opts, WithEmptyResponse(h.cfg.status))
+	} else {
+		opts = append(opts, WithResponse[Out](h.cfg.status))
+	}
+
+	return append(opts, h.cfg.routeOpts...)
+}
+
+func (h *TypedHandler[In, Out]) handlerName() string {
+	return funcName(h.fn)
+}
+
+// describedHandler lets handlers contribute route metadata and a
+// readable name to the route registry.
+type describedHandler interface {
+	routeOptions() []RouteOption
+	handlerName() string
+}
+
+func isEmpty[T any]() bool {
+	return reflect.TypeFor[T]() == reflect.TypeFor[Empty]()
+}
@@ -0,0 +1,152 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+type createItemInput struct {
+	ListID string `path:"list"`
+	Name   string `json:"name" validate:"required"`
+}
+
+type itemView struct {
+	List string `json:"list"`
+	Name string `json:"name"`
+}
+
+var errListClosed = domainerrors.NewCode("LIST_CLOSED").
+	Kind(domainerrors.DomainRule).
+	New("list is closed")
+
+func createItem(_ context.Context, in createItemInput) (itemView, error) {
+	if in.ListID == "closed" {
+		return itemView{}, errListClosed
+	}
+
+	return itemView{List: in.ListID, Name: in.Name}, nil
+}
+
+func typedRouter() *Router {
+	router := NewRouter()
+	router.Handle("POST", "/lists/{list}/items",
+		Handle(createItem, WithStatus(http.StatusCreated)))
+	router.Handle("DELETE", "/lists/{list}",
+		Handle(func(context.Context, Empty) (Empty, error) { return Empty{}, nil }))
+
+	return router
+}
+
+func TestHandle_BindsAndResponds(t *testing.T) {
+	t.Parallel()
+	rr := serveJSON(typedRouter(), "POST", "/lists/l1/items", `{"name":"milk"}`)
+	assertStatus(t, http.StatusCreated, rr)
+	assertBody(t, `{"list":"l1","name":"milk"}`+"\n", rr)
+}
+
+func TestHandle_ValidationError(t *testing.T) {
+	t.Parallel()
+	rr := serveJSON(typedRouter(), "POST", "/lists/l1/items", `{}`)
+	assertStatus(t, http.StatusBadRequest, rr)
+	assertContains(t, rr.Body.String(), "VALIDATION_FAILED")
+}
+
+func TestHandle_UseCaseError(t *testing.T) {
+	t.Parallel()
+	rr := serveJSON(typedRouter(), "POST", "/lists/closed/items", `{"name":"milk"}`)
+	assertStatus(t, http.StatusUnprocessableEntity, rr)
+	assertContains(t, rr.Body.String(), "LIST_CLOSED")
+}
+
+func TestHandle_EmptyInOut(t *testing.T) {
+	t.Parallel()
+	rr := serve(typedRouter(), "DELETE", "/lists/l1", nil)
+	assertStatus(t, http.StatusOK, rr)
+	assertBody(t, "", rr)
+}
+
+func TestHandle_NoContent(t *testing.T) {
+	t.Parallel()
+	h := Handle(func(context.Context, Empty) (string, error) { return "ignored", nil },
+		WithStatus(http.StatusNoContent))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("POST", "/", nil))
+	assertStatus(t, http.StatusNoContent, rr)
+	assertBody(t, "", rr)
+}
+
+func TestHandle_BindOptions(t *testing.T) {
+	t.Parallel()
+	h := Handle(createItem, WithBindOptions(DisallowUnknownFields()))
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"a","x":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	assertStatus(t, http.StatusBadRequest, rr)
+	assertContains(t, rr.Body.String(), "INVALID_JSON")
+}
+
+func TestHandle_RouteMetadata(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	router.Handle("POST", "/lists/{list}/items",
+		Handle(createItem,
+			WithStatus(http.StatusCreated),
+			WithRouteOptions(WithSummary("Create item"), WithErrors(errListClosed)),
+		),
+		WithTags("items"),
+	)
+
+	route := router.Routes()[0]
+	if route.Handler != "httpserver.createItem" {
+		t.Fatalf("unexpected handler name %q", route.Handler)
+	}
+
+	meta := route.Meta
+	if meta.Request != reflect.TypeFor[createItemInput]() {
+		t.Fatalf("unexpected request type %v", meta.Request)
+	}
+
+	wantResp := []RouteResponse{{Status: http.StatusCreated, Type: reflect.TypeFor[itemView]()}}
+	if !reflect.DeepEqual(meta.Responses, wantResp) {
+		t.Fatalf("unexpected responses %+v", meta.Responses)
+	}
+
+	if meta.Summary != "Create item" || meta.Tags[0] != "items" ||
+		meta.Errors[0] != (RouteError{Status: 422, Code: "LIST_CLOSED"}) {
+		t.Fatalf("unexpected meta %+v", meta)
+	}
+}
+
+func TestHandle_PassesContext(t *testing.T) {
+	t.Parallel()
+	type key struct{}
+	var got any
+	h := Handle(func(ctx context.Context, _ Empty) (Empty, error) {
+		got = ctx.Value(key{})
+		return Empty{}, errors.New("boom")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), key{}, "v"))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if got != "v" {
+		t.Fatal("request context not passed")
+	}
+	assertStatus(t, http.StatusInternalServerError, rr)
+}
+
+func serveJSON(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(rr, req)
+	return rr
+}
@@ -0,0 +1,315 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	DefaultMaxFileSize   = 32 << 20
+	DefaultMaxUploadSize = 64 << 20
+	DefaultMaxFiles      = 10
+)
+
+// sniffLen is how much of a file http.DetectContentType looks at.
+const sniffLen = 512
+
+// UploadConfig limits and routes a multipart upload. File parts are
+// streamed to Storage one at a time; nothing is held in memory beyond
+// the sniffing buffer.
+type UploadConfig struct {
+	MaxFileSize  int64 // per file; 0 = DefaultMaxFileSize
+	MaxTotalSize int64 // files and form values; 0 = DefaultMaxUploadSize
+	MaxFiles     int   // 0 = DefaultMaxFiles
+	// AllowedTypes are sniffed media types, e.g. "image/png" or
+	// "image/*"; empty allows any. The client-declared Content-Type of
+	// a part is ignored.
+	AllowedTypes []string
+	Storage      UploadStorage // nil = TempStorage{}
+}
+
+func (c UploadConfig) withDefaults() UploadConfig {
+	if c.MaxFileSize == 0 {
+		c.MaxFileSize = DefaultMaxFileSize
+	}
+
+	if c.MaxTotalSize == 0 {
+		c.MaxTotalSize = DefaultMaxUploadSize
+	}
+
+	if c.MaxFiles == 0 {
+		c.MaxFiles = DefaultMaxFiles
+	}
+
+	if c.Storage == nil {
+		c.Storage = TempStorage{}
+	}
+
+	return c
+}
+
+// UploadStorage receives uploaded files. Save must consume r and
+// return where the file ended up; an error from r (a size limit) must
+// abort the write.
+type UploadStorage interface {
+	Save(ctx context.Context, f UploadedFile, r io.Reader) (location string, err error)
+	Delete(ctx context.Context, location string) error
+}
+
+type UploadedFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"` // sniffed
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"` // hex
+	Location    string `json:"location"`
+}
+
+type Upload struct {
+	Files  []UploadedFile
+	Values url.Values
+
+	storage UploadStorage
+}
+
+// File returns the first file uploaded under field.
+func (u *Upload) File(field string) (UploadedFile, bool) {
+	for _, f := range u.Files {
+		if f.Field == field {
+			return f, true
+		}
+	}
+
+	return UploadedFile{}, false
+}
+
+// RemoveAll deletes every stored file, e.g. temp files once the
+// handler has moved them elsewhere.
+func (u *Upload) RemoveAll(ctx context.Context) error {
+	var errs []error
+
+	for _, f := range u.Files {
+		errs = append(errs, u.storage.Delete(ctx, f.Location))
+	}
+
+	return errors.Join(errs...)
+}
+
+// TempStorage writes uploads to Dir (os.TempDir() when empty); the
+// location is the file path.
+type TempStorage struct {
+	Dir string
+}
+
+func (s TempStorage) Save(_ context.Context, _ UploadedFile, r io.Reader) (string, error) {
+	f, err := os.CreateTemp(s.Dir, "upload-*")
+	if err != nil {
+		return "", fmt.Errorf("httpserver: create temp file: %w", err)
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("httpserver: write temp file: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+func (TempStorage) Delete(_ context.Context, location string) error {
+	if err := os.Remove(location); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// ParseUpload streams a multipart/form-data body into cfg.Storage.
+// On any error the files stored so far are deleted. Limit violations
+// are ErrFileTooLarge, ErrBodyTooLarge (total) and ErrTooManyFiles; a
+// disallowed type is ErrUnsupportedFileType.
+func ParseUpload(r *http.Request, cfg UploadConfig) (*Upload, error) {
+	cfg = cfg.withDefaults()
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	u := &Upload{Values: url.Values{}, storage: cfg.Storage}
+	budget := cfg.MaxTotalSize
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return u, nil
+		}
+
+		if err == nil {
+			err = u.readPart(r.Context(), cfg, part, &budget)
+			_ = part.Close()
+		} else {
+			err = partError(err)
+		}
+
+		if err != nil {
+			_ = u.RemoveAll(context.WithoutCancel(r.Context()))
+			return nil, err
+		}
+	}
+}
+
+func (u *Upload) readPart(ctx context.Context, cfg UploadConfig, part partReader, budget *int64) error {
+	if part.FileName() == "" {
+		cr := &capReader{r: part, max: *budget, err: ErrBodyTooLarge}
+
+		value, err := io.ReadAll(cr)
+		if err != nil {
+			if cr.n > cr.max {
+				return cr.err
+			}
+
+			return partError(err)
+		}
+
+		*budget -= cr.n
+		u.Values.Add(part.FormName(), string(value))
+
+		return nil
+	}
+
+	if len(u.Files) == cfg.MaxFiles {
+		return ErrTooManyFiles
+	}
+
+	head := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return partError(err)
+	}
+
+	head = head[:n]
+	file := UploadedFile{
+		Field:       part.FormName(),
+		Filename:    part.FileName(),
+		ContentType: sniffType(head),
+	}
+
+	if !typeAllowed(cfg.AllowedTypes, file.ContentType) {
+		return ErrUnsupportedFileType.WithDetail("content_type", file.ContentType)
+	}
+
+	cr := &capReader{r: io.MultiReader(bytes.NewReader(head), part), max: cfg.MaxFileSize, err: ErrFileTooLarge}
+	if *budget < cr.max {
+		cr.max, cr.err = *budget, ErrBodyTooLarge
+	}
+
+	hash := sha256.New()
+
+	loc, err := cfg.Storage.Save(ctx, file, io.TeeReader(cr, hash))
+	if err == nil && cr.n > cr.max {
+		_ = cfg.Storage.Delete(ctx, loc)
+	}
+
+	switch {
+	case cr.n > cr.max:
+		return cr.err
+	case cr.readErr != nil:
+		return partError(cr.readErr)
+	case err != nil:
+		return fmt.Errorf("httpserver: store upload %q: %w", file.Filename, err)
+	}
+
+	file.Size, file.SHA256, file.Location = cr.n, hex.EncodeToString(hash.Sum(nil)), loc
+	*budget -= cr.n
+	u.Files = append(u.Files, file)
+
+	return nil
+}
+
+type partReader interface {
+	io.Reader
+	FileName() string
+	FormName() string
+}
+
+// partError reports a failed body read: an http.MaxBytesReader limit
+// or a malformed multipart stream.
+func partError(err error) error {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return ErrBodyTooLarge
+	}
+
+	return ErrInvalidBody.WithCause(err)
+}
+
+// capReader fails with err once more than max bytes were read and
+// remembers body read failures, so they are not mistaken for storage
+// errors.
+type capReader struct {
+	r       io.Reader
+	n       int64
+	max     int64
+	err     error
+	readErr error
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	if c.n > c.max {
+		return n, c.err
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.readErr = err
+	}
+
+	return n, err
+}
+
+func sniffType(head []byte) string {
+	mt, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mt
+}
+
+func typeAllowed(allowed []string, mt string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, a := range allowed {
+		if a == mt {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mt, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,189 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+type uploadPart struct {
+	field, filename string
+	data            []byte
+}
+
+func multipartRequest(t *testing.T, parts ...uploadPart) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		var w io.Writer
+		var err error
+		if p.filename == "" {
+			w, err = mw.CreateFormField(p.field)
+		} else {
+			w, err = mw.CreateFormFile(p.field, p.filename)
+		}
+		assertNoErr(t, err)
+		_, _ = w.Write(p.data)
+	}
+	assertNoErr(t, mw.Close())
+	r := httptest.NewRequest("POST", "/upload", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assertNoErr(t, err)
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+func TestParseUpload_StoresFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	img := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte("x"), 2000)...)
+	r := multipartRequest(t,
+		uploadPart{field: "title", data: []byte("holiday")},
+		uploadPart{field: "photo", filename: "cat.png", data: img},
+	)
+
+	u, err := ParseUpload(r, UploadConfig{Storage: TempStorage{Dir: dir}, AllowedTypes: []string{"image/*"}})
+	assertNoErr(t, err)
+	if u.Values.Get("title") != "holiday" {
+		t.Fatalf("unexpected values: %v", u.Values)
+	}
+	f, ok := u.File("photo")
+	if !ok {
+		t.Fatal("expected photo")
+	}
+	sum := sha256.Sum256(img)
+	if f.Filename != "cat.png" || f.ContentType != "image/png" || f.Size != int64(len(img)) ||
+		f.SHA256 != hex.EncodeToString(sum[:]) || filepath.Dir(f.Location) != dir {
+		t.Fatalf("unexpected file: %+v", f)
+	}
+	stored, err := os.ReadFile(f.Location)
+	assertNoErr(t, err)
+	if !bytes.Equal(stored, img) {
+		t.Fatal("stored content differs")
+	}
+
+	assertNoErr(t, u.RemoveAll(context.Background()))
+	if names := tempFiles(t, dir); len(names) != 0 {
+		t.Fatalf("expected no files, got %v", names)
+	}
+}
+
+func TestParseUpload_Limits(t *testing.T) {
+	t.Parallel()
+	text := []byte(strings.Repeat("hello ", 50))
+	tests := []struct {
+		name  string
+		cfg   UploadConfig
+		parts []uploadPart
+		want  error
+	}{
+		{
+			name:  "file size",
+			cfg:   UploadConfig{MaxFileSize: 100},
+			parts: []uploadPart{{field: "a", filename: "a.txt", data: text}},
+			want:  ErrFileTooLarge,
+		},
+		{
+			name: "total size",
+			cfg:  UploadConfig{MaxTotalSize: 400},
+			parts: []uploadPart{
+				{field: "a", filename: "a.txt", data: text},
+				{field: "b", filename: "b.txt", data: text},
+			},
+			want: ErrBodyTooLarge,
+		},
+		{
+			name:  "values count towards total",
+			cfg:   UploadConfig{MaxTotalSize: 100},
+			parts: []uploadPart{{field: "a", data: text}},
+			want:  ErrBodyTooLarge,
+		},
+		{
+			name: "file count",
+			cfg:  UploadConfig{MaxFiles: 1},
+			parts: []uploadPart{
+				{field: "a", filename: "a.txt", data: text},
+				{field: "b", filename: "b.txt", data: text},
+			},
+			want: ErrTooManyFiles,
+		},
+		{
+			name:  "sniffed type",
+			cfg:   UploadConfig{AllowedTypes: []string{"image/png"}},
+			parts: []uploadPart{{field: "a", filename: "fake.png", data: text}},
+			want:  ErrUnsupportedFileType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			tt.cfg.Storage = TempStorage{Dir: dir}
+			_, err := ParseUpload(multipartRequest(t, tt.parts...), tt.cfg)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if names := tempFiles(t, dir); len(names) != 0 {
+				t.Fatalf("expected cleanup, got %v", names)
+			}
+		})
+	}
+}
+
+func TestParseUpload_Errors(t *testing.T) {
+	t.Parallel()
+	r := httptest.NewRequest("POST", "/upload", strings.NewReader("{}"))
+	r.Header.Set("Content-Type", "application/json")
+	if _, err := ParseUpload(r, UploadConfig{}); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Fatalf("expected ErrUnsupportedMediaType, got %v", err)
+	}
+
+	r = httptest.NewRequest("POST", "/upload", strings.NewReader("--b\r\nbroken"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+	if _, err := ParseUpload(r, UploadConfig{}); !errors.Is(err, ErrInvalidBody) {
+		t.Fatalf("expected ErrInvalidBody, got %v", err)
+	}
+}
+
+type failingStorage struct{}
+
+func (failingStorage) Save(_ context.Context, _ UploadedFile, r io.Reader) (string, error) {
+	_, _ = io.Copy(io.Discard, r)
+	return "", errors.New("disk full")
+}
+
+func (failingStorage) Delete(context.Context, string) error { return nil }
+
+func TestParseUpload_StorageError(t *testing.T) {
+	t.Parallel()
+	r := multipartRequest(t, uploadPart{field: "a", filename: "a.txt", data: []byte("hi")})
+	_, err := ParseUpload(r, UploadConfig{Storage: failingStorage{}})
+	if err == nil || errors.Is(err, ErrInvalidBody) || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected storage error, got %v", err)
+	}
+	rr := httptest.NewRecorder()
+	Error(rr, ErrFileTooLarge)
+	assertStatus(t, http.StatusRequestEntityTooLarge, rr)
+}
@@ -0,0 +1,165 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var uuidPattern = regexp.MustCompile(
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+)
+
+// validateStruct checks `validate:"required,min=1,max=100,len=3,
+// oneof=a b,email,uuid"` tags. Only nil pointers count as absent and
+// skip the rules unless required, so min=1 rejects a zero int and
+// oneof rejects ""; fields that already failed conversion are not
+// validated again.
+func validateStruct(v reflect.Value, errs *[]FieldError) {
+	failed := make(map[string]bool, len(*errs))
+	for _, e := range *errs {
+		failed[e.Field] = true
+	}
+
+	validateFields(v, failed, errs)
+}
+
+func validateFields(
+	v reflect.Value, failed map[string]bool, errs *[]FieldError,
+) {
+	t := v.Type()
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		fv := v.Field(i)
+
+		if sf.Anonymous && fv.Kind() == reflect.Struct {
+			validateFields(fv, failed, errs)
+			continue
+		}
+
+		tag, ok := sf.Tag.Lookup("validate")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		name, source := fieldName(sf)
+		if failed[name] {
+			continue
+		}
+
+		if rule, msg := checkRules(fv, tag); rule != "" {
+			*errs = append(*errs, FieldError{
+				Field:   name,
+				Source:  source,
+				Rule:    rule,
+				Message: msg,
+			})
+		}
+	}
+}
+
+func fieldName(sf reflect.StructField) (string, string) {
+	for _, source := range bindSources {
+		if name, ok := sf.Tag.Lookup(source); ok {
+			return name, source
+		}
+	}
+
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name, "body"
+	}
+
+	return sf.Name, "body"
+}
+
+func checkRules(fv reflect.Value, tag string) (string, string) {
+	rules := strings.Split(tag, ",")
+
+	if fv.IsZero() && slices.Contains(rules, "required") {
+		return "required", "is required"
+	}
+
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return "", ""
+		}
+
+		fv = fv.Elem()
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		if msg := checkRule(fv, name, arg); msg != "" {
+			return name, msg
+		}
+	}
+
+	return "", ""
+}
+
+func checkRule(fv reflect.Value, name, arg string) string {
+	switch name {
+	case "min", "max", "len":
+		return checkBound(fv, name, arg)
+	case "oneof":
+		if !slices.Contains(strings.Fields(arg), fmt.Sprint(fv.Interface())) {
+			return "must be one of: " + arg
+		}
+	case "email":
+		if _, err := mail.ParseAddress(fv.String()); err != nil {
+			return "must be a valid email address"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(fmt.Sprint(fv.Interface())) {
+			return "must be a valid UUID"
+		}
+	}
+
+	return ""
+}
+
+// checkBound compares numbers by value and strings, slices and maps
+// by length.
+func checkBound(fv reflect.Value, name, arg string) string {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return ""
+	}
+
+	var (
+		actual float64
+		unit   string
+	)
+
+	switch fv.Kind() {
+	case reflect.String:
+		actual, unit = float64(utf8.RuneCountInString(fv.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		actual, unit = float64(fv.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = fv.Float()
+	default:
+		return ""
+	}
+
+	switch {
+	case name == "min" && actual < limit:
+		return "must be at least " + arg + unit
+	case name == "max" && actual > limit:
+		return "must be at most " + arg + unit
+	case name == "len" && actual != limit:
+		return "must be exactly " + arg + unit
+	}
+
+	return ""
+}
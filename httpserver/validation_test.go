@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validatedInput struct {
+	Name   string   `query:"name" validate:"required,min=2,max=5"`
+	Age    *int     `query:"age" validate:"min=18,max=120"`
+	Role   *string  `query:"role" validate:"oneof=admin user"`
+	Email  *string  `query:"email" validate:"email"`
+	ID     *string  `query:"id" validate:"uuid"`
+	Zip    *string  `query:"zip" validate:"len=5"`
+	Codes  []string `query:"code" validate:"max=2"`
+	Note   string   `json:"note" validate:"max=3"`
+	hidden string
+}
+
+func TestValidation_Rules(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		query string
+		field string
+		rule  string
+	}{
+		{"", "name", "required"},
+		{"name=a", "name", "min"},
+		{"name=abcdef", "name", "max"},
+		{"name=bob&age=10", "age", "min"},
+		{"name=bob&age=200", "age", "max"},
+		{"name=bob&role=root", "role", "oneof"},
+		{"name=bob&email=nope", "email", "email"},
+		{"name=bob&id=123", "id", "uuid"},
+		{"name=bob&zip=123", "zip", "len"},
+		{"name=bob&code=a,b,c", "code", "max"},
+		{"name=bob&role=", "role", "oneof"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+			_, err := BindRequest[validatedInput](httptest.NewRequest("GET", "/?"+tt.query, nil))
+			fields := fieldErrors(t, err)
+			if len(fields) != 1 || fields[0].Field != tt.field || fields[0].Rule != tt.rule {
+				t.Fatalf("expected %s/%s, got %+v", tt.field, tt.rule, fields)
+			}
+		})
+	}
+}
+
+func TestValidation_ValidInput(t *testing.T) {
+	t.Parallel()
+	q := "name=bob&age=30&role=admin&email=bob@example.com" +
+		"&id=123e4567-e89b-12d3-a456-426614174000&zip=12345&code=a,b"
+	in, err := BindRequest[validatedInput](httptest.NewRequest("GET", "/?"+q, nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if in.hidden != "" {
+		t.Fatal("unexported fields must be ignored")
+	}
+}
+
+func TestValidation_BodyFieldUsesJSONName(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("POST", "/?name=bob", strings.NewReader(`{"note":"toolong"}`))
+	_, err := BindRequest[validatedInput](req)
+	fields := fieldErrors(t, err)
+	if len(fields) != 1 || fields[0].Field != "note" || fields[0].Source != "body" {
+		t.Fatalf("unexpected errors: %+v", fields)
+	}
+}
+
+func TestValidation_SkipsFailedConversion(t *testing.T) {
+	t.Parallel()
+	_, err := BindRequest[validatedInput](httptest.NewRequest("GET", "/?name=bob&age=old", nil))
+	fields := fieldErrors(t, err)
+	if len(fields) != 1 || fields[0].Rule != "type" {
+		t.Fatalf("expected only the type error, got %+v", fields)
+	}
+}
+
+type zeroValueInput struct {
+	Count  int    `json:"count" validate:"min=1"`
+	Status string `json:"status" validate:"oneof=open closed"`
+	Limit  *int   `json:"limit" validate:"min=1"`
+}
+
+func TestValidation_ZeroValuesAreChecked(t *testing.T) {
+	t.Parallel()
+	_, err := BindRequest[zeroValueInput](httptest.NewRequest("POST", "/", strings.NewReader(`{}`)))
+	fields := fieldErrors(t, err)
+	if len(fields) != 2 || fields[0].Field != "count" || fields[1].Field != "status" {
+		t.Fatalf("expected count and status errors, got %+v", fields)
+	}
+
+	_, err = BindRequest[zeroValueInput](httptest.NewRequest("POST", "/",
+		strings.NewReader(`{"count":1,"status":"open","limit":0}`)))
+	if fields := fieldErrors(t, err); len(fields) != 1 || fields[0].Field != "limit" {
+		t.Fatalf("expected present zero pointer to be checked, got %+v", fields)
+	}
+}
+
+func TestValidation_JSONTypeErrorJoinsFieldErrors(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("POST", "/?name=a", strings.NewReader(`{"count":"many","status":"open"}`))
+	_, err := BindRequest[struct {
+		Name string `query:"name" validate:"min=2"`
+		zeroValueInput
+	}](req)
+	fields := fieldErrors(t, err)
+	if len(fields) != 2 || fields[0].Field != "count" || fields[0].Source != "body" ||
+		fields[0].Rule != "type" || fields[1].Field != "name" {
+		t.Fatalf("unexpected errors: %+v", fields)
+	}
+}
@@ -0,0 +1,357 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+// Versioning strategies: where a request names its API version.
+//
+//	path   — a path segment after the prefix: /api/v2/users
+//	header — a request header, API-Version: 2 by default
+//	accept — the Accept media type: application/vnd.<vendor>.v2+json
+//	         or a version parameter: application/json; version=2
+const (
+	VersionPath   = "path"
+	VersionHeader = "header"
+	VersionAccept = "accept"
+)
+
+const DefaultVersionHeader = "API-Version"
+
+// versionParam is the ServeMux wildcard holding the path version.
+const versionParam = "apiVersion"
+
+var ErrUnsupportedVersion = domainerrors.NewCode("UNSUPPORTED_API_VERSION").
+	Kind(domainerrors.Validation).
+	New("unsupported API version")
+
+type VersioningConfig struct {
+	Strategy string // default VersionPath
+	// Header carries the version for VersionHeader and echoes the
+	// resolved version in every response; "" = DefaultVersionHeader.
+	Header string
+	Vendor string // VersionAccept: the <vendor> in application/vnd.<vendor>.v2+json
+	// Default applies when a header or accept request names no
+	// version; "" = the latest registered version.
+	Default string
+	Logger  Logger // deprecated version usage; nil = discard
+}
+
+func (c VersioningConfig) withDefaults() VersioningConfig {
+	if c.Strategy == "" {
+		c.Strategy = VersionPath
+	}
+
+	if c.Header == "" {
+		c.Header = DefaultVersionHeader
+	}
+
+	if c.Logger == nil {
+		c.Logger = noopLogger{}
+	}
+
+	return c
+}
+
+// VersionOptions mark a version as deprecated. Requests to it get
+// Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers; the first
+// one per route is logged, Usage counts them all.
+type VersionOptions struct {
+	Deprecated time.Time // zero = not deprecated
+	Sunset     time.Time // planned removal; zero = not announced
+	Link       string    // migration guide, sent as rel="deprecation"
+}
+
+// VersionSet routes requests between API versions. Versions must be
+// added oldest first: a route missing from the requested version is
+// served by the newest earlier version that has it.
+type VersionSet struct {
+	parent *Router
+	cfg    VersioningConfig
+
+	mu       sync.RWMutex
+	versions []*apiVersion
+	routes   map[string]map[*apiVersion]http.Handler
+	logged   sync.Map // version + route pattern of deprecated use already logged
+}
+
+type apiVersion struct {
+	name  string
+	index int
+	opts  VersionOptions
+	set   *VersionSet
+	hits  atomic.Int64
+}
+
+// Versioned creates a version set mounted at prefix. It inherits the
+// router's middleware.
+func (rt *Router) Versioned(prefix string, cfg VersioningConfig) *VersionSet {
+	return &VersionSet{
+		parent: rt.Group(prefix),
+		cfg:    cfg.withDefaults(),
+		routes: make(map[string]map[*apiVersion]http.Handler),
+	}
+}
+
+// Version adds a version and returns the router to register its routes
+// on. With VersionPath its routes live under prefix/name; "v2" and "2"
+// name the same version in requests.
+func (vs *VersionSet) Version(name string, opts ...VersionOptions) *Router {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	v := &apiVersion{name: name, index: len(vs.versions), set: vs}
+	if len(opts) > 0 {
+		v.opts = opts[0]
+	}
+
+	vs.versions = append(vs.versions, v)
+
+	r := vs.parent.Group("")
+	r.version = v
+
+	if vs.cfg.Strategy == VersionPath {
+		r.prefix += "/" + name
+	}
+
+	return r
+}
+
+// Usage returns the number of requests served per requested version,
+// to tell when a deprecated version can be removed.
+func (vs *VersionSet) Usage() map[string]int64 {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	usage := make(map[string]int64, len(vs.versions))
+	for _, v := range vs.versions {
+		usage[v.name] = v.hits.Load()
+	}
+
+	return usage
+}
+
+type versionKey struct{}
+
+// APIVersion returns the version the client requested, as named in
+// VersionSet.Version; "" outside versioned routes.
+func APIVersion(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey{}).(string)
+	return v
+}
+
+// handle registers h for v. The mux gets one dispatching handler per
+// method and pattern, shared by all versions.
+func (v *apiVersion) handle(method, prefix, pattern string, h http.Handler) {
+	vs := v.set
+	path := prefix + pattern
+
+	if vs.cfg.Strategy == VersionPath {
+		rest := strings.TrimPrefix(prefix, vs.parent.prefix+"/"+v.name)
+		path = vs.parent.prefix + "/{" + versionParam + "}" + rest + pattern
+	}
+
+	key := method + " " + path
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	handlers, ok := vs.routes[key]
+	if !ok {
+		handlers = make(map[*apiVersion]http.Handler)
+		vs.routes[key] = handlers
+		vs.parent.mux.Handle(key, vs.dispatch(key))
+	}
+
+	if _, dup := handlers[v]; dup {
+		panic(fmt.Sprintf("httpserver: %s already registered for version %s", key, v.name))
+	}
+
+	handlers[v] = h
+}
+
+func (vs *VersionSet) dispatch(key string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, err := vs.requested(r)
+		if err != nil {
+			vs.fail(w, r, err)
+			return
+		}
+
+		h := vs.lookup(key, v)
+		if h == nil {
+			vs.fail(w, r, ErrRouteNotFound)
+			return
+		}
+
+		v.hits.Add(1)
+		vs.annotate(w, r, v)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v.name)))
+	})
+}
+
+// fail answers through the set's middleware, like unmatched routes.
+func (vs *VersionSet) fail(w http.ResponseWriter, r *http.Request, err error) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { Error(w, err) })
+	if err == ErrRouteNotFound {
+		h = vs.parent.shared.serveNotFound
+	}
+
+	applyChain(h, vs.parent.middleware).ServeHTTP(w, r)
+}
+
+// lookup finds the handler of v or, failing that, of the newest
+// earlier version.
+func (vs *VersionSet) lookup(key string, v *apiVersion) http.Handler {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	handlers := vs.routes[key]
+	for i := v.index; i >= 0; i-- {
+		if h, ok := handlers[vs.versions[i]]; ok {
+			return h
+		}
+	}
+
+	return nil
+}
+
+func (vs *VersionSet) requested(r *http.Request) (*apiVersion, error) {
+	var name string
+
+	switch vs.cfg.Strategy {
+	case VersionPath:
+		v := vs.find(r.PathValue(versionParam))
+		if v == nil {
+			return nil, ErrRouteNotFound
+		}
+
+		return v, nil
+	case VersionHeader:
+		name = strings.TrimSpace(r.Header.Get(vs.cfg.Header))
+	case VersionAccept:
+		name = acceptVersion(r.Header.Get("Accept"), vs.cfg.Vendor)
+	}
+
+	if name == "" {
+		return vs.defaultVersion(), nil
+	}
+
+	v := vs.find(name)
+	if v == nil {
+		if vs.cfg.Strategy == VersionAccept {
+			return nil, ErrNotAcceptable.WithDetail("version", name)
+		}
+
+		return nil, ErrUnsupportedVersion.WithDetail("version", name)
+	}
+
+	return v, nil
+}
+
+func (vs *VersionSet) find(name string) *apiVersion {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	want := normalizeVersion(name)
+	for _, v := range vs.versions {
+		if normalizeVersion(v.name) == want {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func (vs *VersionSet) defaultVersion() *apiVersion {
+	if v := vs.find(vs.cfg.Default); vs.cfg.Default != "" && v != nil {
+		return v
+	}
+
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	return vs.versions[len(vs.versions)-1]
+}
+
+// annotate echoes the version, keeps shared caches apart and flags
+// deprecated versions.
+func (vs *VersionSet) annotate(w http.ResponseWriter, r *http.Request, v *apiVersion) {
+	h := w.Header()
+	h.Set(vs.cfg.Header, v.name)
+
+	switch vs.cfg.Strategy {
+	case VersionHeader:
+		h.Add("Vary", vs.cfg.Header)
+	case VersionAccept:
+		h.Add("Vary", "Accept")
+	}
+
+	opts := v.opts
+	if opts.Deprecated.IsZero() {
+		return
+	}
+
+	h.Set("Deprecation", "@"+strconv.FormatInt(opts.Deprecated.Unix(), 10))
+
+	if !opts.Sunset.IsZero() {
+		h.Set("Sunset", opts.Sunset.UTC().Format(http.TimeFormat))
+	}
+
+	if opts.Link != "" {
+		h.Add("Link", "<"+opts.Link+`>; rel="deprecation"`)
+	}
+
+	if _, seen := vs.logged.LoadOrStore(v.name+" "+r.Pattern, struct{}{}); seen {
+		return
+	}
+
+	vs.cfg.Logger.Info("httpserver: deprecated API version used",
+		"version", v.name,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"pattern", r.Pattern,
+		"user_agent", r.UserAgent(),
+	)
+}
+
+// acceptVersion extracts the version from the first Accept media range
+// naming one.
+func acceptVersion(accept, vendor string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if v := params["version"]; v != "" {
+			return v
+		}
+
+		if vendor == "" {
+			continue
+		}
+
+		_, subtype, _ := strings.Cut(mt, "/")
+		if rest, ok := strings.CutPrefix(subtype, "vnd."+strings.ToLower(vendor)+"."); ok {
+			v, _, _ := strings.Cut(rest, "+")
+			return v
+		}
+	}
+
+	return ""
+}
+
+func normalizeVersion(name string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "v")
+}
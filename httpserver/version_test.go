@@ -0,0 +1,147 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func versionText(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s@%s", body, APIVersion(r.Context()))
+	}
+}
+
+func versionedRouter(cfg VersioningConfig) (*Router, *VersionSet) {
+	router := NewRouter()
+	api := router.Versioned("/api", cfg)
+	v1 := api.Version("v1", VersionOptions{
+		Deprecated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		Link:       "https://example.com/migrate",
+	})
+	v2 := api.Version("v2")
+	v1.GET("/users", versionText("list1"))
+	v1.GET("/users/{id}", versionText("get1"))
+	v2.GET("/users", versionText("list2"))
+	v2.Group("/admin").DELETE("/users/{id}", versionText("del2"))
+	return router, api
+}
+
+func TestVersioned_Path(t *testing.T) {
+	t.Parallel()
+	router, api := versionedRouter(VersioningConfig{})
+
+	rr := serve(router, "GET", "/api/v2/users", nil)
+	assertBody(t, "list2@v2", rr)
+	assertHeader(t, "API-Version", "v2", rr)
+	assertHeader(t, "Deprecation", "", rr)
+
+	assertBody(t, "get1@v2", serve(router, "GET", "/api/v2/users/7", nil))
+	assertBody(t, "del2@v2", serve(router, "DELETE", "/api/v2/admin/users/7", nil))
+	assertStatus(t, http.StatusNotFound, serve(router, "DELETE", "/api/v1/admin/users/7", nil))
+	assertStatus(t, http.StatusNotFound, serve(router, "GET", "/api/v9/users", nil))
+
+	rr = serve(router, "GET", "/api/1/users", nil)
+	assertBody(t, "list1@v1", rr)
+	assertHeader(t, "Deprecation", "@1767225600", rr)
+	assertHeader(t, "Sunset", "Thu, 31 Dec 2026 00:00:00 GMT", rr)
+	assertHeader(t, "Link", `<https://example.com/migrate>; rel="deprecation"`, rr)
+
+	if usage := api.Usage(); usage["v1"] != 1 || usage["v2"] != 3 {
+		t.Fatalf("unexpected usage: %v", usage)
+	}
+}
+
+func TestVersioned_Header(t *testing.T) {
+	t.Parallel()
+	router, _ := versionedRouter(VersioningConfig{Strategy: VersionHeader, Header: "X-API-Version"})
+
+	request := func(version string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/users/3", nil)
+		if version != "" {
+			r.Header.Set("X-API-Version", version)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr := request("")
+	assertBody(t, "get1@v2", rr)
+	assertHeader(t, "Vary", "X-API-Version", rr)
+	assertBody(t, "get1@v1", request("1"))
+
+	rr = request("7")
+	assertStatus(t, http.StatusBadRequest, rr)
+	assertContains(t, rr.Body.String(), "UNSUPPORTED_API_VERSION")
+}
+
+func TestVersioned_Accept(t *testing.T) {
+	t.Parallel()
+	router, _ := versionedRouter(VersioningConfig{Strategy: VersionAccept, Vendor: "acme", Default: "v1"})
+
+	tests := []struct{ accept, body string }{
+		{"application/vnd.acme.v2+json", "list2@v2"},
+		{"application/json; version=2", "list2@v2"},
+		{"text/html, application/vnd.acme.v1+json", "list1@v1"},
+		{"application/json", "list1@v1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/users", nil)
+		r.Header.Set("Accept", tt.accept)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+		assertBody(t, tt.body, rr)
+		assertHeader(t, "Vary", "Accept", rr)
+	}
+
+	r := httptest.NewRequest("GET", "/api/users", nil)
+	r.Header.Set("Accept", "application/vnd.acme.v3+json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, r)
+	assertStatus(t, http.StatusNotAcceptable, rr)
+}
+
+func TestVersioned_AcceptRespondsWithVendorMediaType(t *testing.T) {
+	t.Parallel()
+	router := NewRouter()
+	api := router.Versioned("/api", VersioningConfig{Strategy: VersionAccept, Vendor: "acme"})
+	api.Version("v2").GET("/users", func(w http.ResponseWriter, r *http.Request) {
+		Respond(w, r, http.StatusOK, map[string]string{"version": APIVersion(r.Context())})
+	})
+
+	r := httptest.NewRequest("GET", "/api/users", nil)
+	r.Header.Set("Accept", "application/vnd.acme.v2+json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, r)
+	assertStatus(t, http.StatusOK, rr)
+	assertHeader(t, "Content-Type", "application/json", rr)
+	assertContains(t, rr.Body.String(), `"version":"v2"`)
+}
+
+func TestVersioned_LogsDeprecatedUsageAndRoutes(t *testing.T) {
+	t.Parallel()
+	log := &recordLogger{}
+	router, _ := versionedRouter(VersioningConfig{Logger: log})
+	serve(router, "GET", "/api/v2/users", nil)
+	serve(router, "GET", "/api/v1/users", nil)
+	serve(router, "GET", "/api/v1/users", nil)
+	if len(log.lines) != 1 || !log.contains("deprecated API version used [version v1") {
+		t.Fatalf("unexpected log: %v", log.lines)
+	}
+	serve(router, "GET", "/api/v1/users/7", nil)
+	if len(log.lines) != 2 {
+		t.Fatalf("expected one line per route, got %v", log.lines)
+	}
+
+	var versions []string
+	for _, r := range router.Routes() {
+		versions = append(versions, r.Version+" "+r.Method+" "+r.Pattern)
+	}
+	assertContains(t, strings.Join(versions, "\n"), "v2 DELETE /api/v2/admin/users/{id}")
+	assertContains(t, strings.Join(versions, "\n"), "v1 GET /api/v1/users/{id}")
+}
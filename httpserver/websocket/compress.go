@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Context takeover is disabled in both directions, so each message is
+// compressed independently and no per-connection window is kept.
+const deflateResponse = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+
+var (
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+	// inflateTail restores the stripped sync marker and adds an empty
+	// final block so the reader ends cleanly.
+	inflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+)
+
+var flateWriters = sync.Pool{
+	New: func() any {
+		w, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return w
+	},
+}
+
+// acceptsDeflate reports whether one of the offered permessage-deflate
+// configurations can be served with a 32 KiB window.
+func acceptsDeflate(values []string) bool {
+	for _, v := range values {
+		for _, offer := range strings.Split(v, ",") {
+			if deflateOfferOK(offer) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func deflateOfferOK(offer string) bool {
+	params := strings.Split(offer, ";")
+	if strings.TrimSpace(params[0]) != "permessage-deflate" {
+		return false
+	}
+
+	for _, p := range params[1:] {
+		name, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+
+		switch strings.TrimSpace(name) {
+		case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+		case "server_max_window_bits":
+			if strings.Trim(strings.TrimSpace(value), `"`) != "15" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, _ := flateWriters.Get().(*flate.Writer)
+	defer flateWriters.Put(w)
+
+	w.Reset(&buf)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+func inflate(data []byte, limit int64) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(inflateTail)))
+	defer func() { _ = r.Close() }()
+
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(out)) > limit {
+		return nil, ErrMessageTooLarge
+	}
+
+	return out, nil
+}
@@ -0,0 +1,448 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	finBit  = 0x80
+	rsv1Bit = 0x40
+	rsv2Bit = 0x20
+	rsv3Bit = 0x10
+	maskBit = 0x80
+
+	maxControlPayload = 125
+)
+
+// Conn is a server-side WebSocket connection. One goroutine may read
+// and any number may write concurrently. Close must not run
+// concurrently with ReadMessage; use CloseWithCode from other
+// goroutines instead.
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	req      *http.Request
+	cfg      Config
+	protocol string
+	compress bool
+
+	writeMu   sync.Mutex
+	closeSent atomic.Bool
+	closeOnce sync.Once
+	readErr   error
+	untrack   func()
+}
+
+func newConn(
+	c net.Conn, br *bufio.Reader, r *http.Request,
+	cfg Config, protocol string, compress bool,
+) *Conn {
+	return &Conn{
+		conn:     c,
+		br:       br,
+		req:      r,
+		cfg:      cfg,
+		protocol: protocol,
+		compress: compress,
+		untrack:  func() {},
+	}
+}
+
+// Request is the upgrade request; its context carries middleware
+// values such as the request ID.
+func (c *Conn) Request() *http.Request { return c.req }
+
+func (c *Conn) Subprotocol() string { return c.protocol }
+
+func (c *Conn) Compressed() bool { return c.compress }
+
+func (c *Conn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// ReadMessage returns the next data message, answering pings and the
+// close handshake along the way. Once it fails every later call
+// returns the same error; a peer close surfaces as *CloseError.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	typ, data, err := c.readMessage()
+	if err != nil {
+		c.readErr = err
+		c.failRead(err)
+
+		return 0, nil, err
+	}
+
+	return typ, data, nil
+}
+
+func (c *Conn) ReadJSON(v any) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+
+	if c.closeSent.Load() {
+		return ErrClosed
+	}
+
+	if !c.compress {
+		return c.writeFrame(byte(typ), data, false)
+	}
+
+	compressed, err := deflate(data)
+	if err != nil {
+		return err
+	}
+
+	return c.writeFrame(byte(typ), compressed, true)
+}
+
+func (c *Conn) WriteJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteMessage(TextMessage, data)
+}
+
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: ping payload too large")
+	}
+
+	return c.writeFrame(opPing, data, false)
+}
+
+// CloseWithCode starts the close handshake; the peer's reply ends the
+// pending ReadMessage. Reads give up after Config.CloseTimeout.
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	if !c.closeSent.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+
+	err := c.writeFrame(opClose, payload, false)
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.cfg.CloseTimeout))
+
+	return err
+}
+
+// Close performs the close handshake with CloseNormal, waits for the
+// peer's close frame and closes the network connection.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		if c.readErr == nil {
+			_ = c.CloseWithCode(CloseNormal, "")
+
+			for c.readErr == nil {
+				_, _, _ = c.ReadMessage()
+			}
+		}
+
+		_ = c.conn.Close()
+		c.untrack()
+	})
+
+	return nil
+}
+
+func (c *Conn) failRead(err error) {
+	var pe *protocolError
+	if errors.As(err, &pe) {
+		_ = c.CloseWithCode(pe.code, pe.reason)
+	}
+
+	_ = c.conn.Close()
+}
+
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ        MessageType
+		compressed bool
+		started    bool
+		buf        []byte
+	)
+
+	for {
+		h, err := c.readHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if h.opcode >= opClose {
+			if err := c.handleControl(h); err != nil {
+				return 0, nil, err
+			}
+
+			continue
+		}
+
+		switch {
+		case h.opcode == opContinuation && !started:
+			return 0, nil, &protocolError{CloseProtocolError, "unexpected continuation frame"}
+		case h.opcode == opContinuation && h.rsv1:
+			return 0, nil, &protocolError{CloseProtocolError, "RSV1 set on continuation frame"}
+		case h.opcode != opContinuation && started:
+			return 0, nil, &protocolError{CloseProtocolError, "expected continuation frame"}
+		case h.opcode != opContinuation:
+			if h.rsv1 && !c.compress {
+				return 0, nil, &protocolError{CloseProtocolError, "RSV1 set without compression"}
+			}
+
+			started, typ, compressed = true, MessageType(h.opcode), h.rsv1
+		}
+
+		if int64(len(buf))+h.length > c.cfg.ReadLimit {
+			return 0, nil, &protocolError{CloseMessageTooBig, ErrMessageTooLarge.Error()}
+		}
+
+		payload, err := c.readPayload(h)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		buf = append(buf, payload...)
+
+		if h.fin {
+			break
+		}
+	}
+
+	if compressed {
+		var err error
+		if buf, err = inflate(buf, c.cfg.ReadLimit); err != nil {
+			if errors.Is(err, ErrMessageTooLarge) {
+				return 0, nil, &protocolError{CloseMessageTooBig, err.Error()}
+			}
+
+			return 0, nil, &protocolError{CloseInvalidPayload, "invalid compressed payload"}
+		}
+	}
+
+	if typ == TextMessage && !utf8.Valid(buf) {
+		return 0, nil, &protocolError{CloseInvalidPayload, "invalid UTF-8 in text message"}
+	}
+
+	return typ, buf, nil
+}
+
+func (c *Conn) handleControl(h frameHeader) error {
+	payload, err := c.readPayload(h)
+	if err != nil {
+		return err
+	}
+
+	switch h.opcode {
+	case opPing:
+		if !c.closeSent.Load() {
+			_ = c.writeFrame(opPong, payload, false)
+		}
+	case opClose:
+		ce, err := parseClose(payload)
+		if err != nil {
+			return err
+		}
+
+		reply := ce.Code
+		if reply == CloseNoStatus {
+			reply = CloseNormal
+		}
+
+		_ = c.CloseWithCode(reply, "")
+
+		return ce
+	}
+
+	return nil
+}
+
+func parseClose(payload []byte) (*CloseError, error) {
+	switch {
+	case len(payload) == 0:
+		return &CloseError{Code: CloseNoStatus}, nil
+	case len(payload) == 1:
+		return nil, &protocolError{CloseProtocolError, "invalid close payload"}
+	}
+
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return nil, &protocolError{CloseProtocolError, "invalid close code"}
+	}
+
+	if !utf8.Valid(payload[2:]) {
+		return nil, &protocolError{CloseInvalidPayload, "invalid UTF-8 in close reason"}
+	}
+
+	return &CloseError{Code: code, Reason: string(payload[2:])}, nil
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	default:
+		return code >= 3000 && code <= 4999
+	}
+}
+
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode byte
+	length int64
+	mask   [4]byte
+}
+
+func (c *Conn) readHeader() (frameHeader, error) {
+	var (
+		h frameHeader
+		b [8]byte
+	)
+
+	if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+		return h, err
+	}
+
+	if b[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return h, &protocolError{CloseProtocolError, "reserved bits set"}
+	}
+
+	h.fin = b[0]&finBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = b[0] & 0x0f
+
+	if b[1]&maskBit == 0 {
+		return h, &protocolError{CloseProtocolError, "client frame is not masked"}
+	}
+
+	h.length = int64(b[1] & 0x7f)
+
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+			return h, err
+		}
+
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, b[:8]); err != nil {
+			return h, err
+		}
+
+		n := binary.BigEndian.Uint64(b[:8])
+		if n > 1<<63-1 {
+			return h, &protocolError{CloseProtocolError, "invalid frame length"}
+		}
+
+		h.length = int64(n)
+	}
+
+	if _, err := io.ReadFull(c.br, h.mask[:]); err != nil {
+		return h, err
+	}
+
+	switch h.opcode {
+	case opContinuation, opText, opBinary:
+	case opClose, opPing, opPong:
+		if !h.fin || h.length > maxControlPayload {
+			return h, &protocolError{CloseProtocolError, "invalid control frame"}
+		}
+
+		if h.rsv1 {
+			return h, &protocolError{CloseProtocolError, "RSV1 set on control frame"}
+		}
+	default:
+		return h, &protocolError{CloseProtocolError, "unknown opcode"}
+	}
+
+	return h, nil
+}
+
+func (c *Conn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= h.mask[i%4]
+	}
+
+	return payload, nil
+}
+
+// writeFrame writes one unfragmented, unmasked server frame.
+func (c *Conn) writeFrame(op byte, payload []byte, rsv1 bool) error {
+	header := make([]byte, 2, 10)
+	header[0] = finBit | op
+
+	if rsv1 {
+		header[0] |= rsv1Bit
+	}
+
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.cfg.WriteTimeout))
+
+	bufs := net.Buffers{header, payload}
+	if _, err := bufs.WriteTo(c.conn); err != nil {
+		return fmt.Errorf("websocket: write: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+
+	domainerrors "github.com/shuldan/errors"
+)
+
+var ErrNotWebSocket = domainerrors.NewCode("WEBSOCKET_UPGRADE_REQUIRED").
+	Kind(domainerrors.Validation).
+	New("request is not a WebSocket upgrade")
+
+var ErrUnsupportedVersion = domainerrors.NewCode("WEBSOCKET_VERSION_UNSUPPORTED").
+	Kind(domainerrors.Validation).
+	New("unsupported WebSocket version")
+
+var ErrOriginNotAllowed = domainerrors.NewCode("WEBSOCKET_ORIGIN_NOT_ALLOWED").
+	Kind(domainerrors.Authorization).
+	New("WebSocket origin not allowed")
+
+var ErrHijackUnsupported = domainerrors.NewCode("WEBSOCKET_HIJACK_UNSUPPORTED").
+	Kind(domainerrors.Internal).
+	New("response writer does not support hijacking")
+
+var (
+	ErrMessageTooLarge = errors.New("websocket: message exceeds read limit")
+	ErrClosed          = errors.New("websocket: connection closed")
+)
+
+// Close codes from RFC 6455, section 7.4.1.
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// CloseError is returned by ReadMessage once the peer has closed the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with code %d: %s", e.Code, e.Reason)
+}
+
+func IsCloseError(err error, codes ...int) bool {
+	var ce *CloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+
+	if len(codes) == 0 {
+		return true
+	}
+
+	for _, c := range codes {
+		if ce.Code == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+type protocolError struct {
+	code   int
+	reason string
+}
+
+func (e *protocolError) Error() string {
+	return "websocket: " + e.reason
+}
@@ -0,0 +1,53 @@
+package websocket
+
+import (
+	"net/http"
+	"sync"
+)
+
+// servers maps each *http.Server that upgraded a connection to its
+// live connections. Hijacked connections are invisible to
+// http.Server.Shutdown, so a shutdown hook sends them CloseGoingAway.
+var servers sync.Map
+
+type connSet struct {
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func track(r *http.Request, c *Conn) {
+	srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server)
+	if !ok {
+		return
+	}
+
+	v, loaded := servers.LoadOrStore(srv, &connSet{conns: make(map[*Conn]struct{})})
+	set, _ := v.(*connSet)
+
+	if !loaded {
+		srv.RegisterOnShutdown(set.closeAll)
+	}
+
+	set.mu.Lock()
+	set.conns[c] = struct{}{}
+	set.mu.Unlock()
+
+	c.untrack = func() {
+		set.mu.Lock()
+		delete(set.conns, c)
+		set.mu.Unlock()
+	}
+}
+
+func (s *connSet) closeAll() {
+	s.mu.Lock()
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.CloseWithCode(CloseGoingAway, "server shutting down")
+	}
+}
@@ -0,0 +1,233 @@
+// Package websocket implements RFC 6455 WebSocket connections (with
+// RFC 7692 permessage-deflate) on top of net/http using only the
+// standard library.
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+)
+
+const (
+	DefaultReadLimit    = 1 << 20
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultCloseTimeout = 5 * time.Second
+
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+type Config struct {
+	// Subprotocols are offered in order of preference.
+	Subprotocols []string
+	// CheckOrigin accepts or rejects the handshake; nil allows requests
+	// without Origin and those whose Origin host equals Host.
+	CheckOrigin func(r *http.Request) bool
+	// ReadLimit caps the size of a (decompressed) message; 0 = 1 MiB.
+	ReadLimit int64
+	// WriteTimeout bounds every frame write; 0 = 10s.
+	WriteTimeout time.Duration
+	// CloseTimeout is how long to wait for the peer's close frame;
+	// 0 = 5s.
+	CloseTimeout time.Duration
+	// EnableCompression negotiates permessage-deflate when offered.
+	EnableCompression bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.ReadLimit <= 0 {
+		c.ReadLimit = DefaultReadLimit
+	}
+
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = DefaultWriteTimeout
+	}
+
+	if c.CloseTimeout <= 0 {
+		c.CloseTimeout = DefaultCloseTimeout
+	}
+
+	return c
+}
+
+// Handler upgrades the request and runs fn with the request context,
+// so values set by middleware (request ID, auth) stay available. The
+// connection is closed with the close handshake when fn returns.
+func Handler(
+	cfg Config, fn func(ctx context.Context, c *Conn),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r, cfg)
+		if err != nil {
+			return
+		}
+
+		defer func() { _ = c.Close() }()
+
+		fn(r.Context(), c)
+	}
+}
+
+// Upgrade performs the opening handshake. On failure it has already
+// answered with an error response.
+func Upgrade(w http.ResponseWriter, r *http.Request, cfg Config) (*Conn, error) {
+	cfg = cfg.withDefaults()
+
+	if err := checkHandshake(r, cfg); err != nil {
+		if errors.Is(err, ErrUnsupportedVersion) {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+		}
+
+		httpserver.Error(w, err)
+
+		return nil, err
+	}
+
+	protocol := selectSubprotocol(r, cfg.Subprotocols)
+	compress := cfg.EnableCompression && acceptsDeflate(r.Header.Values("Sec-WebSocket-Extensions"))
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		httpserver.Error(w, ErrHijackUnsupported.WithCause(err))
+		return nil, ErrHijackUnsupported
+	}
+
+	// Deadlines from the server's Read/WriteTimeout must not apply to
+	// the upgraded connection.
+	_ = netConn.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	b.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n")
+
+	if protocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n")
+	}
+
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: " + deflateResponse + "\r\n")
+	}
+
+	b.WriteString("\r\n")
+
+	_ = netConn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	c := newConn(netConn, bufferedReader(netConn, brw), r, cfg, protocol, compress)
+	track(r, c)
+
+	return c, nil
+}
+
+func checkHandshake(r *http.Request, cfg Config) error {
+	if r.Method != http.MethodGet ||
+		!headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return ErrNotWebSocket
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return ErrUnsupportedVersion
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key")); err != nil || len(key) != 16 {
+		return ErrNotWebSocket.WithDetail("reason", "invalid Sec-WebSocket-Key")
+	}
+
+	checkOrigin := cfg.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+
+	if !checkOrigin(r) {
+		return ErrOriginNotAllowed
+	}
+
+	return nil
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+
+	for _, s := range supported {
+		for _, o := range offered {
+			if s == o {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+
+	return tokens
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bufferedReader keeps bytes the server already read past the request
+// (a client may send its first frame right after the handshake).
+func bufferedReader(c net.Conn, brw *bufio.ReadWriter) *bufio.Reader {
+	if brw == nil || brw.Reader.Buffered() == 0 {
+		return bufio.NewReader(c)
+	}
+
+	pending, _ := brw.Reader.Peek(brw.Reader.Buffered())
+
+	return bufio.NewReader(io.MultiReader(bytes.NewReader(pending), c))
+}
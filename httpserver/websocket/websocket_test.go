@@ -0,0 +1,387 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shuldan/framework/httpserver"
+	"github.com/shuldan/framework/httpserver/middleware"
+)
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+	resp *http.Response
+}
+
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func dial(t *testing.T, srv *httptest.Server, path string, header http.Header) *testClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", testKey)
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	return &testClient{t: t, conn: conn, br: br, resp: resp}
+}
+
+func (c *testClient) writeFrame(b0 byte, payload []byte) {
+	c.t.Helper()
+
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	mask := [4]byte{1, 2, 3, 4}
+	frame = append(frame, mask[:]...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) readFrame() (byte, []byte) {
+	c.t.Helper()
+
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		c.t.Fatalf("read frame: %v", err)
+	}
+
+	n := int(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		_, _ = io.ReadFull(c.br, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		_, _ = io.ReadFull(c.br, b[:])
+		n = int(binary.BigEndian.Uint64(b[:]))
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatalf("read payload: %v", err)
+	}
+
+	return h[0], payload
+}
+
+func (c *testClient) expectClose(code int) {
+	c.t.Helper()
+
+	b0, payload := c.readFrame()
+	if b0&0x0f != opClose || len(payload) < 2 {
+		c.t.Fatalf("expected close frame, got opcode %x", b0&0x0f)
+	}
+
+	if got := int(binary.BigEndian.Uint16(payload)); got != code {
+		c.t.Fatalf("expected close code %d, got %d (%s)", code, got, payload[2:])
+	}
+}
+
+func closePayload(code int) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(code))
+}
+
+func echoServer(t *testing.T, cfg Config) *httptest.Server {
+	t.Helper()
+
+	router := httpserver.NewRouter()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Logging(nopLogger{}))
+	router.GET("/ws", Handler(cfg, func(ctx context.Context, c *Conn) {
+		_ = c.WriteMessage(TextMessage, []byte("id:"+middleware.IDFromContext(ctx)))
+
+		for {
+			typ, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if err := c.WriteMessage(typ, data); err != nil {
+				return
+			}
+		}
+	}))
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+func TestHandshake_ThroughRouterAndLogging(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{})
+	c := dial(t, srv, "/ws", http.Header{"X-Request-Id": {"req-7"}})
+
+	if c.resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", c.resp.StatusCode)
+	}
+
+	if got := c.resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+
+	if _, payload := c.readFrame(); string(payload) != "id:req-7" {
+		t.Fatalf("request context not propagated: %q", payload)
+	}
+}
+
+func TestEcho_FragmentedAndLarge(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{})
+	c := dial(t, srv, "/ws", nil)
+	c.readFrame()
+
+	c.writeFrame(opText, []byte("hel"))
+	c.writeFrame(finBit|opPing, []byte("p"))
+	c.writeFrame(finBit|opContinuation, []byte("lo"))
+
+	if b0, payload := c.readFrame(); b0&0x0f != opPong || string(payload) != "p" {
+		t.Fatalf("expected pong, got %x %q", b0, payload)
+	}
+
+	if b0, payload := c.readFrame(); b0 != finBit|opText || string(payload) != "hello" {
+		t.Fatalf("unexpected echo %x %q", b0, payload)
+	}
+
+	big := bytes.Repeat([]byte{7}, 70000)
+	c.writeFrame(finBit|opBinary, big)
+
+	if b0, payload := c.readFrame(); b0 != finBit|opBinary || !bytes.Equal(payload, big) {
+		t.Fatalf("large binary echo failed: %x len=%d", b0, len(payload))
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{})
+	c := dial(t, srv, "/ws", nil)
+	c.readFrame()
+
+	c.writeFrame(finBit|opClose, append(closePayload(CloseGoingAway), "bye"...))
+	c.expectClose(CloseGoingAway)
+
+	if _, err := c.br.ReadByte(); !errors.Is(err, io.EOF) {
+		t.Fatalf("server must close TCP after handshake, got %v", err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{ReadLimit: 10})
+	c := dial(t, srv, "/ws", nil)
+	c.readFrame()
+
+	c.writeFrame(finBit|opText, []byte("this is too long"))
+	c.expectClose(CloseMessageTooBig)
+}
+
+func TestProtocolErrors(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		b0      byte
+		payload []byte
+		code    int
+	}{
+		"invalid utf8":    {finBit | opText, []byte{0xff, 0xfe}, CloseInvalidPayload},
+		"bad opcode":      {finBit | 0x3, nil, CloseProtocolError},
+		"orphan continue": {finBit | opContinuation, []byte("x"), CloseProtocolError},
+		"rsv1 no deflate": {finBit | rsv1Bit | opText, []byte("x"), CloseProtocolError},
+		"bad close code":  {finBit | opClose, closePayload(999), CloseProtocolError},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			srv := echoServer(t, Config{})
+			c := dial(t, srv, "/ws", nil)
+			c.readFrame()
+			c.writeFrame(tc.b0, tc.payload)
+			c.expectClose(tc.code)
+		})
+	}
+}
+
+func TestUnmaskedFrameRejected(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{})
+	c := dial(t, srv, "/ws", nil)
+	c.readFrame()
+
+	_, _ = c.conn.Write([]byte{finBit | opText, 1, 'x'})
+	c.expectClose(CloseProtocolError)
+}
+
+func TestHandshakeErrors(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{})
+
+	rr := httptest.NewRecorder()
+	Handler(Config{}, func(context.Context, *Conn) {}).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for plain request, got %d", rr.Code)
+	}
+
+	c := dial(t, srv, "/ws", http.Header{"Sec-Websocket-Version": {"8"}})
+	if c.resp.StatusCode != http.StatusBadRequest || c.resp.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Fatalf("unexpected version response %d", c.resp.StatusCode)
+	}
+
+	c = dial(t, srv, "/ws", http.Header{"Origin": {"https://evil.example"}})
+	if c.resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for foreign origin, got %d", c.resp.StatusCode)
+	}
+}
+
+func TestSubprotocol(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{Subprotocols: []string{"v2", "v1"}})
+	c := dial(t, srv, "/ws", http.Header{"Sec-Websocket-Protocol": {"v1, v2"}})
+
+	if got := c.resp.Header.Get("Sec-WebSocket-Protocol"); got != "v2" {
+		t.Fatalf("expected v2, got %q", got)
+	}
+}
+
+func TestPerMessageDeflate(t *testing.T) {
+	t.Parallel()
+	srv := echoServer(t, Config{EnableCompression: true})
+	c := dial(t, srv, "/ws", http.Header{
+		"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"},
+	})
+
+	if got := c.resp.Header.Get("Sec-WebSocket-Extensions"); got != deflateResponse {
+		t.Fatalf("deflate not negotiated: %q", got)
+	}
+
+	c.readFrame()
+
+	msg := strings.Repeat("compress me ", 100)
+	compressed, err := deflate([]byte(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.writeFrame(finBit|rsv1Bit|opText, compressed)
+
+	b0, payload := c.readFrame()
+	if b0&rsv1Bit == 0 || len(payload) >= len(msg) {
+		t.Fatalf("response not compressed: %x len=%d", b0, len(payload))
+	}
+
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(payload), bytes.NewReader(inflateTail)))
+	out, _ := io.ReadAll(r)
+	if string(out) != msg {
+		t.Fatalf("round trip mismatch")
+	}
+}
+
+func TestAcceptsDeflate(t *testing.T) {
+	t.Parallel()
+	cases := map[string]bool{
+		"permessage-deflate":                                                true,
+		"permessage-deflate; server_max_window_bits=15":                     true,
+		"permessage-deflate; server_max_window_bits=10":                     false,
+		"permessage-deflate; server_max_window_bits=10, permessage-deflate": true,
+		"x-webkit-deflate-frame":                                            false,
+		"permessage-deflate; unknown":                                       false,
+	}
+
+	for offer, want := range cases {
+		if got := acceptsDeflate([]string{offer}); got != want {
+			t.Errorf("%q: expected %v, got %v", offer, want, got)
+		}
+	}
+}
+
+func TestModuleStop_ClosesConnections(t *testing.T) {
+	t.Parallel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	router := httpserver.NewRouter()
+	router.GET("/ws", Handler(Config{}, func(_ context.Context, c *Conn) {
+		defer wg.Done()
+		_, _, err := c.ReadMessage()
+		if !IsCloseError(err, CloseGoingAway) {
+			t.Errorf("expected going-away close, got %v", err)
+		}
+	}))
+
+	m := httpserver.NewModule(router, httpserver.Config{Host: "127.0.0.1", Port: 0})
+	if err := m.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_ = m.Start(context.Background())
+
+	c := dial(t, &httptest.Server{URL: "http://" + m.Addr()}, "/ws", nil)
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.expectClose(CloseGoingAway)
+	c.writeFrame(finBit|opClose, closePayload(CloseGoingAway))
+	wg.Wait()
+}
@@ -10,12 +10,14 @@ import (
 	"github.com/shuldan/config"
 
 	"github.com/shuldan/framework/logger"
+	"github.com/shuldan/framework/upgrade"
 )
 
 type Kernel struct {
 	cfg      *config.Config
 	log      *logger.Logger
 	console  *cli.Console
+	upgrader *upgrade.Upgrader
 	cleanups []func()
 }
 
@@ -33,10 +35,16 @@ func NewKernel(opts ...KernelOption) (*Kernel, error) {
 	log := buildLogger(cfg, o)
 	console := buildConsole(cfg)
 
+	upgrader, err := buildUpgrader(o, log)
+	if err != nil {
+		return nil, fmt.Errorf("framework: graceful restart: %w", err)
+	}
+
 	return &Kernel{
-		cfg:     cfg,
-		log:     log,
-		console: console,
+		cfg:      cfg,
+		log:      log,
+		console:  console,
+		upgrader: upgrader,
 	}, nil
 }
 
@@ -48,6 +56,12 @@ func (k *Kernel) Logger() *logger.Logger {
 	return k.log
 }
 
+// Upgrader is nil unless WithGracefulRestart is set. Pass it as
+// httpserver.Config.Handoff and register it as the last module.
+func (k *Kernel) Upgrader() *upgrade.Upgrader {
+	return k.upgrader
+}
+
 func (k *Kernel) Command(cmds ...cli.Command) {
 	for _, cmd := range cmds {
 		if err := k.console.Register(cmd); err != nil {
@@ -66,6 +80,9 @@ func (k *Kernel) OnShutdown(fn func()) {
 func (k *Kernel) Run(ctx context.Context, args []string) error {
 	defer k.runCleanups()
 
+	ctx, cancel := k.watchUpgrade(ctx)
+	defer cancel()
+
 	return k.console.Run(ctx, os.Stdin, os.Stdout, args)
 }
 
@@ -77,9 +94,23 @@ func (k *Kernel) RunWith(
 ) error {
 	defer k.runCleanups()
 
+	ctx, cancel := k.watchUpgrade(ctx)
+	defer cancel()
+
 	return k.console.Run(ctx, in, out, args)
 }
 
+// watchUpgrade cancels ctx after a successful handoff, so the running
+// command shuts its modules down the same way as on SIGTERM.
+func (k *Kernel) watchUpgrade(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if k.upgrader != nil {
+		go k.upgrader.Watch(ctx, cancel)
+	}
+
+	return ctx, cancel
+}
+
 func (k *Kernel) runCleanups() {
 	for i := len(k.cleanups) - 1; i >= 0; i-- {
 		k.cleanups[i]()
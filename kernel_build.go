@@ -5,6 +5,7 @@ import (
 	"github.com/shuldan/config"
 
 	"github.com/shuldan/framework/logger"
+	"github.com/shuldan/framework/upgrade"
 )
 
 func buildConfig(o *kernelOptions) (*config.Config, error) {
@@ -92,3 +93,16 @@ func buildConsole(
 
 	return cli.New(consoleOpts...)
 }
+
+func buildUpgrader(o *kernelOptions, log *logger.Logger) (*upgrade.Upgrader, error) {
+	if o.upgrade == nil {
+		return nil, nil
+	}
+
+	cfg := *o.upgrade
+	if cfg.Logger == nil {
+		cfg.Logger = log
+	}
+
+	return upgrade.New(cfg)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/shuldan/config"
 
 	"github.com/shuldan/framework/logger"
+	"github.com/shuldan/framework/upgrade"
 )
 
 type KernelOption func(*kernelOptions)
@@ -14,6 +15,7 @@ type kernelOptions struct {
 	profileEnvVar string
 	logger        *logger.Logger
 	config        *config.Config
+	upgrade       *upgrade.Config
 }
 
 func defaultKernelOptions() *kernelOptions {
@@ -53,3 +55,11 @@ func WithConfig(cfg *config.Config) KernelOption {
 		o.config = cfg
 	}
 }
+
+// WithGracefulRestart enables listener handoff on SIGUSR2: a new copy
+// of the binary takes over and Run returns once it reports readiness.
+func WithGracefulRestart(cfg upgrade.Config) KernelOption {
+	return func(o *kernelOptions) {
+		o.upgrade = &cfg
+	}
+}
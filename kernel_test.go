@@ -11,6 +11,7 @@ import (
 	"github.com/shuldan/config"
 
 	"github.com/shuldan/framework/logger"
+	"github.com/shuldan/framework/upgrade"
 )
 
 func TestNewKernel_WithConfig(t *testing.T) {
@@ -280,3 +281,25 @@ func (c *stubCommand) Execute(
 	}
 	return nil
 }
+
+func TestNewKernel_WithGracefulRestart(t *testing.T) {
+	t.Parallel()
+	k, err := NewKernel(WithConfig(config.FromMap(map[string]any{})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.Upgrader() != nil {
+		t.Fatal("expected no upgrader by default")
+	}
+
+	k, err = NewKernel(
+		WithConfig(config.FromMap(map[string]any{})),
+		WithGracefulRestart(upgrade.Config{}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k.Upgrader() == nil || k.Upgrader().HasParent() {
+		t.Fatal("expected upgrader without parent")
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
@@ -8,7 +9,8 @@ import (
 )
 
 type Logger struct {
-	slog *slog.Logger
+	slog  *slog.Logger
+	level *slog.LevelVar
 }
 
 type Config struct {
@@ -44,14 +46,33 @@ func (l *Logger) Error(msg string, args ...any) {
 }
 
 func (l *Logger) With(args ...any) *Logger {
-	return &Logger{slog: l.slog.With(args...)}
+	return &Logger{slog: l.slog.With(args...), level: l.level}
+}
+
+// Level returns the current minimum level: debug, info, warn or error.
+func (l *Logger) Level() string {
+	return strings.ToLower(l.level.Level().String())
+}
+
+// SetLevel changes the level at runtime for this logger and every
+// logger derived from it with With.
+func (l *Logger) SetLevel(s string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return fmt.Errorf("logger: %w", err)
+	}
+
+	l.level.Set(level)
+
+	return nil
 }
 
 func newLogger(w io.Writer, cfg Config) *Logger {
-	level := parseLevel(cfg.Level)
+	level := new(slog.LevelVar)
+	level.Set(parseLevel(cfg.Level))
 	handler := newHandler(cfg.Format, w, level)
 
-	return &Logger{slog: slog.New(handler)}
+	return &Logger{slog: slog.New(handler), level: level}
 }
 
 func parseLevel(s string) slog.Level {
@@ -76,7 +97,7 @@ func parseOutput(s string) io.Writer {
 }
 
 func newHandler(
-	format string, w io.Writer, level slog.Level,
+	format string, w io.Writer, level slog.Leveler,
 ) slog.Handler {
 	opts := &slog.HandlerOptions{Level: level}
 
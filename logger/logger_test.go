@@ -68,6 +68,27 @@ func TestLogger_With_AddsContext(t *testing.T) {
 	assertContains(t, output, "access granted")
 }
 
+func TestLogger_SetLevel(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	log := NewWithWriter(&buf, Config{Level: "warn"})
+	child := log.With("module", "auth")
+	if log.Level() != "warn" {
+		t.Fatalf("expected warn, got %q", log.Level())
+	}
+	if err := log.SetLevel("debug"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	child.Debug("now visible")
+	assertContains(t, buf.String(), "now visible")
+	if child.Level() != "debug" {
+		t.Fatalf("expected derived logger to follow, got %q", child.Level())
+	}
+	if err := log.SetLevel("verbose"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
 func TestLogger_ParseLevel_AllValues(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -229,6 +229,10 @@ func (r *Runner) forEachTarget(
 	return nil
 }
 
+func DriverDialect(driver string) migrator.Dialect {
+	return driverToDialect(driver)
+}
+
 func driverToDialect(driver string) migrator.Dialect {
 	d := strings.ToLower(driver)
 
@@ -99,6 +99,13 @@ func TestDriverToDialect(t *testing.T) {
 	}
 }
 
+func TestDriverDialect(t *testing.T) {
+	t.Parallel()
+	if DriverDialect("pgx") != migrator.DialectPostgreSQL {
+		t.Fatal("expected PostgreSQL dialect for pgx")
+	}
+}
+
 func TestEnsureLog_Nil(t *testing.T) {
 	t.Parallel()
 	l := ensureLog(nil)
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package upgrade
+
+import "os"
+
+// Listener handoff relies on descriptor inheritance and SIGUSR2.
+var upgradeSignals []os.Signal
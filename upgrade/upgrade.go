@@ -0,0 +1,446 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	envListeners = "FRAMEWORK_UPGRADE_LISTENERS"
+	envReadyFD   = "FRAMEWORK_UPGRADE_READY_FD"
+
+	// firstFD is where exec.Cmd.ExtraFiles start in the child.
+	firstFD = 3
+
+	DefaultReadyTimeout = 30 * time.Second
+)
+
+var (
+	ErrUpgradeInProgress = errors.New("upgrade: already in progress")
+	ErrUpgraded          = errors.New("upgrade: process already handed over")
+	ErrNotReady          = errors.New("upgrade: process has not reported readiness")
+	ErrChildNotReady     = errors.New("upgrade: child exited before becoming ready")
+	ErrReadyTimeout      = errors.New("upgrade: child readiness timed out")
+)
+
+type Logger interface {
+	Info(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Info(string, ...any)  {}
+func (noopLogger) Error(string, ...any) {}
+
+type Config struct {
+	ReadyTimeout time.Duration // 0 = DefaultReadyTimeout
+	Executable   string        // "" = os.Executable()
+	Args         []string      // nil = os.Args[1:]
+	Env          []string      // appended to the inherited environment
+	Logger       Logger
+}
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrader hands listening sockets over to a freshly exec'd copy of the
+// binary. The child reports readiness through a pipe; only then does
+// the parent stop accepting and drain.
+type Upgrader struct {
+	cfg Config
+	log Logger
+
+	mu        sync.Mutex
+	inherited map[string]net.Listener
+	keys      []string
+	tracked   map[string]net.Listener
+	ready     *os.File
+	upgrading bool
+	done      chan struct{}
+}
+
+// New picks up listeners and the readiness pipe passed by a parent
+// process, if any.
+func New(cfg Config) (*Upgrader, error) {
+	if cfg.ReadyTimeout == 0 {
+		cfg.ReadyTimeout = DefaultReadyTimeout
+	}
+
+	log := cfg.Logger
+	if log == nil {
+		log = noopLogger{}
+	}
+
+	u := &Upgrader{
+		cfg:       cfg,
+		log:       log,
+		inherited: make(map[string]net.Listener),
+		tracked:   make(map[string]net.Listener),
+		done:      make(chan struct{}),
+	}
+
+	if err := u.inherit(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (u *Upgrader) inherit() error {
+	if raw := os.Getenv(envListeners); raw != "" {
+		var keys []string
+		if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+			return fmt.Errorf("upgrade: parse %s: %w", envListeners, err)
+		}
+
+		for i, key := range keys {
+			f := os.NewFile(uintptr(firstFD+i), key)
+			ln, err := net.FileListener(f)
+			_ = f.Close()
+
+			if err != nil {
+				return fmt.Errorf("upgrade: inherit %s: %w", key, err)
+			}
+
+			u.inherited[key] = ln
+		}
+	}
+
+	if raw := os.Getenv(envReadyFD); raw != "" {
+		fd, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("upgrade: parse %s: %w", envReadyFD, err)
+		}
+
+		u.ready = os.NewFile(uintptr(fd), "upgrade-ready")
+	}
+
+	return nil
+}
+
+// HasParent reports whether this process was started by Upgrade and
+// has not signalled readiness yet.
+func (u *Upgrader) HasParent() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.ready != nil
+}
+
+// Inherited returns the listener passed by the parent under key. Each
+// listener is handed out once. Inherited and Track are safe on a nil
+// Upgrader, so Kernel.Upgrader() can be passed as a handoff as is.
+func (u *Upgrader) Inherited(key string) net.Listener {
+	if u == nil {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	ln := u.inherited[key]
+	delete(u.inherited, key)
+
+	return ln
+}
+
+// Track registers a listener to be passed to the next child.
+func (u *Upgrader) Track(key string, ln net.Listener) {
+	if u == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.tracked[key]; !ok {
+		u.keys = append(u.keys, key)
+	}
+
+	u.tracked[key] = ln
+}
+
+// Ready tells the parent this process serves traffic. It is a no-op
+// without a parent.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.ready == nil {
+		return nil
+	}
+
+	_, err := u.ready.Write([]byte{1})
+	_ = u.ready.Close()
+	u.ready = nil
+
+	if err != nil {
+		return fmt.Errorf("upgrade: notify parent: %w", err)
+	}
+
+	u.log.Info("upgrade: reported readiness to parent", "pid", os.Getpid())
+
+	return nil
+}
+
+// Done is closed once a child has taken over the listeners.
+func (u *Upgrader) Done() <-chan struct{} {
+	return u.done
+}
+
+// Upgrade starts a new process with the tracked listeners and waits
+// until it reports readiness. On error the child is killed and this
+// process keeps serving.
+func (u *Upgrader) Upgrade(ctx context.Context) error {
+	files, keys, err := u.begin()
+	if err != nil {
+		return err
+	}
+
+	defer closeFiles(files)
+
+	pid, err := u.spawn(ctx, files, keys)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.upgrading = false
+
+	if err != nil {
+		u.log.Error("upgrade: handoff failed", "error", err)
+		return err
+	}
+
+	// The child now owns socket files; closing ours must not unlink them.
+	for _, ln := range u.tracked {
+		if ul, ok := ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+
+	close(u.done)
+	u.log.Info("upgrade: child is ready, draining", "child_pid", pid)
+
+	return nil
+}
+
+func (u *Upgrader) begin() ([]*os.File, []string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	select {
+	case <-u.done:
+		return nil, nil, ErrUpgraded
+	default:
+	}
+
+	if u.upgrading {
+		return nil, nil, ErrUpgradeInProgress
+	}
+
+	if u.ready != nil {
+		return nil, nil, ErrNotReady
+	}
+
+	files := make([]*os.File, 0, len(u.keys))
+
+	for _, key := range u.keys {
+		fl, ok := u.tracked[key].(filer)
+		if !ok {
+			closeFiles(files)
+			return nil, nil, fmt.Errorf("upgrade: listener %s cannot be passed on", key)
+		}
+
+		f, err := fl.File()
+		if err != nil {
+			closeFiles(files)
+			return nil, nil, fmt.Errorf("upgrade: dup %s: %w", key, err)
+		}
+
+		files = append(files, f)
+	}
+
+	u.upgrading = true
+
+	return files, append([]string(nil), u.keys...), nil
+}
+
+func (u *Upgrader) spawn(ctx context.Context, files []*os.File, keys []string) (int, error) {
+	cmd, err := u.command(files, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("upgrade: ready pipe: %w", err)
+	}
+	defer r.Close()
+
+	cmd.ExtraFiles = append(files, w)
+
+	err = cmd.Start()
+	_ = w.Close()
+
+	if err != nil {
+		return 0, fmt.Errorf("upgrade: start child: %w", err)
+	}
+
+	u.log.Info("upgrade: child started", "child_pid", cmd.Process.Pid)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			ready <- ErrChildNotReady
+			return
+		}
+		ready <- nil
+	}()
+
+	timer := time.NewTimer(u.cfg.ReadyTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+	case waitErr := <-exited:
+		err = errors.Join(ErrChildNotReady, waitErr)
+	case <-timer.C:
+		err = ErrReadyTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return 0, err
+	}
+
+	return cmd.Process.Pid, nil
+}
+
+func (u *Upgrader) command(files []*os.File, keys []string) (*exec.Cmd, error) {
+	exe := u.cfg.Executable
+	if exe == "" {
+		var err error
+		if exe, err = os.Executable(); err != nil {
+			return nil, fmt.Errorf("upgrade: locate executable: %w", err)
+		}
+	}
+
+	args := u.cfg.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+
+	encoded, err := json.Marshal(keys)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: encode listeners: %w", err)
+	}
+
+	env := childEnv(os.Environ())
+	env = append(env, u.cfg.Env...)
+	env = append(env,
+		envListeners+"="+string(encoded),
+		envReadyFD+"="+strconv.Itoa(firstFD+len(files)),
+	)
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd, nil
+}
+
+// childEnv drops handoff and systemd activation variables: the child
+// receives every socket through envListeners instead.
+func childEnv(environ []string) []string {
+	out := make([]string, 0, len(environ))
+
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case envListeners, envReadyFD, "LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES":
+			continue
+		}
+
+		out = append(out, kv)
+	}
+
+	return out
+}
+
+// Watch runs Upgrade on SIGUSR2 until ctx is done and calls upgraded
+// after a successful handoff. Failed attempts are logged and the
+// process keeps serving.
+func (u *Upgrader) Watch(ctx context.Context, upgraded func()) {
+	if len(upgradeSignals) == 0 {
+		return
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, upgradeSignals...)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigCh:
+			u.log.Info("upgrade: signal received", "signal", sig.String())
+
+			if err := u.Upgrade(ctx); err != nil {
+				continue
+			}
+
+			upgraded()
+
+			return
+		}
+	}
+}
+
+func (u *Upgrader) Name() string { return "upgrade" }
+
+func (u *Upgrader) Init(_ context.Context) error { return nil }
+
+// Start reports readiness; register the Upgrader after every module
+// that has to be serving before the parent drains.
+func (u *Upgrader) Start(_ context.Context) error { return u.Ready() }
+
+// Stop closes inherited listeners nobody claimed.
+func (u *Upgrader) Stop(_ context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, ln := range u.inherited {
+		_ = ln.Close()
+		delete(u.inherited, key)
+	}
+
+	return nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
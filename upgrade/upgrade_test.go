@@ -0,0 +1,192 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+const (
+	helperEnv = "UPGRADE_TEST_CHILD"
+	helperKey = "tcp://test"
+)
+
+// TestHelperChild is the process started by Upgrade in the tests below.
+func TestHelperChild(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		t.Skip("helper process")
+	}
+
+	if mode == "fail" {
+		return
+	}
+
+	u, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln := u.Inherited(helperKey)
+	if ln == nil {
+		t.Fatal("listener not inherited")
+	}
+
+	served := make(chan struct{})
+	srv := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "child")
+			close(served)
+		}),
+	}
+	go func() { _ = srv.Serve(ln) }()
+
+	if err := u.Ready(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+	}
+
+	_ = srv.Close()
+}
+
+func helperConfig(mode string) Config {
+	return Config{
+		ReadyTimeout: 5 * time.Second,
+		Executable:   os.Args[0],
+		Args:         []string{"-test.run=^TestHelperChild$"},
+		Env:          []string{helperEnv + "=" + mode},
+	}
+}
+
+func TestUpgrade_HandsOverListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := New(helperConfig("serve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Track(helperKey, ln)
+
+	if err := u.Upgrade(context.Background()); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+
+	select {
+	case <-u.Done():
+	default:
+		t.Fatal("expected Done to be closed")
+	}
+
+	// The parent stops accepting; the shared socket stays open in the child.
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatalf("GET after handoff: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "child" {
+		t.Fatalf("expected response from child, got %q", body)
+	}
+
+	if err := u.Upgrade(context.Background()); !errors.Is(err, ErrUpgraded) {
+		t.Fatalf("expected ErrUpgraded, got %v", err)
+	}
+}
+
+func TestUpgrade_ChildNotReady(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	u, err := New(helperConfig("fail"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Track(helperKey, ln)
+
+	if err := u.Upgrade(context.Background()); !errors.Is(err, ErrChildNotReady) {
+		t.Fatalf("expected ErrChildNotReady, got %v", err)
+	}
+
+	select {
+	case <-u.Done():
+		t.Fatal("Done must stay open after a failed upgrade")
+	default:
+	}
+
+	// The parent keeps its listener and may try again.
+	conn, err := net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("listener closed after failed upgrade: %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestUpgrader_WithoutParent(t *testing.T) {
+	t.Parallel()
+	u, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.HasParent() {
+		t.Fatal("expected no parent")
+	}
+	if err := u.Start(context.Background()); err != nil {
+		t.Fatalf("Ready without parent: %v", err)
+	}
+	if u.Inherited("tcp://missing") != nil {
+		t.Fatal("expected no inherited listener")
+	}
+}
+
+func TestUpgrade_UntransferableListener(t *testing.T) {
+	t.Parallel()
+	u, err := New(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Track("custom", fakeListener{})
+
+	if err := u.Upgrade(context.Background()); err == nil {
+		t.Fatal("expected error for listener without File")
+	}
+}
+
+func TestChildEnv(t *testing.T) {
+	t.Parallel()
+	env := childEnv([]string{"PATH=/bin", "LISTEN_FDS=2", envReadyFD + "=5", "HOME=/root"})
+	if len(env) != 2 || env[0] != "PATH=/bin" || env[1] != "HOME=/root" {
+		t.Fatalf("unexpected env %v", env)
+	}
+}
+
+type fakeListener struct{ net.Listener }
+
+func TestUpgrader_NilHandoff(t *testing.T) {
+	t.Parallel()
+	var u *Upgrader
+	u.Track("tcp://x", nil)
+	if u.Inherited("tcp://x") != nil {
+		t.Fatal("expected nil listener")
+	}
+}